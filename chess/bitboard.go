@@ -0,0 +1,205 @@
+package chess
+
+import "math/bits"
+
+// A set of squares, one bit per square.  Bit i is the square with index i = rank*8 + file, so a1 is bit 0
+// and h8 is bit 63.
+type bitboard uint64
+
+const allSquares = ^bitboard(0)
+
+func squareIndex(square vector2) int {
+	return square.y*8 + square.x
+}
+
+func indexToSquare(index int) vector2 {
+	return vector2{index % 8, index / 8}
+}
+
+func squareBitboard(index int) bitboard {
+	return 1 << index
+}
+
+func (b bitboard) has(index int) bool {
+	return b&squareBitboard(index) != 0
+}
+
+func (b bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// Index of the lowest square in the set.  The set must not be empty.
+func (b bitboard) first() int {
+	return bits.TrailingZeros64(uint64(b))
+}
+
+// Removes the lowest square from the set and returns its index
+func (b *bitboard) popFirst() int {
+	index := b.first()
+	*b &= *b - 1
+	return index
+}
+
+var (
+	knightMoveVectors = []vector2{{2, 1}, {2, -1}, {-2, 1}, {-2, -1}, {1, 2}, {1, -2}, {-1, 2}, {-1, -2}}
+	kingMoveVectors   = []vector2{{1, 1}, {1, 0}, {1, -1}, {0, 1}, {0, -1}, {-1, 1}, {-1, 0}, {-1, -1}}
+	rookMoveVectors   = []vector2{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopMoveVectors = []vector2{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+// Precomputed attack sets, indexed by square index
+var (
+	knightAttacks [64]bitboard
+	kingAttacks   [64]bitboard
+	pawnAttacks   [3][64]bitboard // By pawn color
+
+	// Squares strictly between two squares sharing a rank, file or diagonal, and the full line through them.
+	// Both are empty for squares that don't share a line.
+	betweenSquares [64][64]bitboard
+	lineSquares    [64][64]bitboard
+)
+
+// Magic bitboard lookup for a sliding piece on one square: the relevant blockers, multiplied by the magic
+// number and shifted, index the attack set for that blocker arrangement
+type magicEntry struct {
+	mask    bitboard
+	magic   uint64
+	shift   uint
+	attacks []bitboard
+}
+
+var rookMagics, bishopMagics [64]magicEntry
+
+// Magic numbers found by random search over sparse 64 bit values
+var rookMagicNumbers = [64]uint64{
+	0x018010a040018000, 0x0040002000401001, 0x290010a841e00100, 0x29001000050900a0,
+	0x4080030400800800, 0x1200040200100801, 0x2200208200040851, 0x220000820425004c,
+	0x0104800740008020, 0x0420400020005000, 0x0844801000200480, 0x4004808008001000,
+	0x4009000410080100, 0x0003000400020900, 0x4804000810020104, 0x0074800641800900,
+	0x0862818014400020, 0x0040048020004480, 0x11a1010040200012, 0x0020828010000800,
+	0x0848808004020800, 0x4522808004000200, 0x0000010100020004, 0x400206000092411c,
+	0x818004444000a000, 0x0180a000c0005002, 0x000b104100200100, 0x24022202000a4010,
+	0x0100040080080080, 0x0002010200080490, 0x0180390400221098, 0x0410008200010044,
+	0x0310400089800020, 0x08c0804009002902, 0x1004402001001504, 0x0105021001000920,
+	0x0000040080800801, 0x0a02001002000804, 0x0108284204005041, 0x0008004082002411,
+	0x02802281c0028001, 0x0009044000910020, 0x0000200010008080, 0x0040201001010008,
+	0x8000080004008080, 0x3010400420080110, 0x0000414210040008, 0x0010348400460001,
+	0x0080002000401040, 0x0460200088400080, 0x8201822000100280, 0x0600100008008280,
+	0x00c0800800040080, 0x0024040080020080, 0x22c11a0108100c00, 0x0204008114104200,
+	0x8800800010290041, 0x0000401500228206, 0x8002a00011090041, 0x0000042008100101,
+	0x0283000800100205, 0x0002008810010402, 0x0490102200880104, 0x0800010920940042,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x8040229e24002080, 0x4008589084004000, 0x001000c081000001, 0x1a84040088a00240,
+	0x0801104008021044, 0x0002080484040000, 0x0002048a09401000, 0x1001004202014040,
+	0x0424844404040408, 0x0000040812084200, 0x0012080240420000, 0x4044080681020029,
+	0x00000405a0050208, 0x0100082804904000, 0xcc01070082114000, 0x2010220084110901,
+	0x00400c1010212102, 0x800a802004810608, 0x109000180230c010, 0x0008400424010009,
+	0x400a800c00a00387, 0x0001008020a01000, 0x8001302482901000, 0x2100a10486051001,
+	0x4c10100104200220, 0x0001200010042140, 0x00040a0005080100, 0x4289080011004100,
+	0x4001001001004020, 0x1828020840900400, 0x0000852042080206, 0x0002102000841106,
+	0x32018808c0401009, 0x8052100280041804, 0x2009004800010801, 0xa012008020820200,
+	0x00104a0020020080, 0x0400980202004100, 0x0402042040910820, 0x0101010112020440,
+	0x0200a8080804c041, 0x0002350108046011, 0x0002060202008100, 0x1804004204808802,
+	0x10004208a4010200, 0x22d0600810410020, 0x0809410404000080, 0x0028081080800020,
+	0x414c210802100180, 0x1100808090112010, 0x1412c20100884104, 0x000018a042021041,
+	0x0036805002021009, 0x0462061002120419, 0x4008200114450001, 0x0810040808404600,
+	0x400082241202400a, 0x8040004202012020, 0x100090089c008800, 0x0013000000841104,
+	0x1104088404104402, 0x2000410960080084, 0x0802080810109200, 0x5810028204040212,
+}
+
+func init() {
+	for index := range 64 {
+		square := indexToSquare(index)
+		knightAttacks[index] = stepAttacks(square, knightMoveVectors)
+		kingAttacks[index] = stepAttacks(square, kingMoveVectors)
+		pawnAttacks[white][index] = stepAttacks(square, []vector2{{-1, 1}, {1, 1}})
+		pawnAttacks[black][index] = stepAttacks(square, []vector2{{-1, -1}, {1, -1}})
+
+		rookMagics[index] = newMagicEntry(index, rookMagicNumbers[index], rookMoveVectors)
+		bishopMagics[index] = newMagicEntry(index, bishopMagicNumbers[index], bishopMoveVectors)
+	}
+
+	for from := range 64 {
+		for _, moveVector := range kingMoveVectors {
+			line := slidingAttacks(from, 0, []vector2{moveVector, {-moveVector.x, -moveVector.y}}) | squareBitboard(from)
+			between := bitboard(0)
+			for square := indexToSquare(from).add(moveVector); onBoard(square); square = square.add(moveVector) {
+				to := squareIndex(square)
+				betweenSquares[from][to] = between
+				lineSquares[from][to] = line
+				between |= squareBitboard(to)
+			}
+		}
+	}
+}
+
+func onBoard(square vector2) bool {
+	return square.x >= 0 && square.x < 8 && square.y >= 0 && square.y < 8
+}
+
+func stepAttacks(square vector2, moveVectors []vector2) bitboard {
+	attacks := bitboard(0)
+	for _, moveVector := range moveVectors {
+		if target := square.add(moveVector); onBoard(target) {
+			attacks |= squareBitboard(squareIndex(target))
+		}
+	}
+	return attacks
+}
+
+// Attacks of a slider along moveVectors, stopping at (and including) the first occupied square of each ray.
+// Only used to build the magic tables.
+func slidingAttacks(index int, occupied bitboard, moveVectors []vector2) bitboard {
+	attacks := bitboard(0)
+	for _, moveVector := range moveVectors {
+		for square := indexToSquare(index).add(moveVector); onBoard(square); square = square.add(moveVector) {
+			attacks |= squareBitboard(squareIndex(square))
+			if occupied.has(squareIndex(square)) {
+				break
+			}
+		}
+	}
+	return attacks
+}
+
+func newMagicEntry(index int, magic uint64, moveVectors []vector2) magicEntry {
+	// Blockers on the edge of the board never change the attacks, so they are left out of the mask
+	mask := bitboard(0)
+	for _, moveVector := range moveVectors {
+		square := indexToSquare(index).add(moveVector)
+		for onBoard(square.add(moveVector)) {
+			mask |= squareBitboard(squareIndex(square))
+			square = square.add(moveVector)
+		}
+	}
+
+	entry := magicEntry{mask: mask, magic: magic, shift: uint(64 - mask.count())}
+	entry.attacks = make([]bitboard, 1<<mask.count())
+
+	// Walk every subset of the mask with the carry-rippler trick
+	occupied := bitboard(0)
+	for {
+		entry.attacks[entry.index(occupied)] = slidingAttacks(index, occupied, moveVectors)
+		occupied = (occupied - mask) & mask
+		if occupied == 0 {
+			break
+		}
+	}
+	return entry
+}
+
+func (m *magicEntry) index(occupied bitboard) uint64 {
+	return (uint64(occupied&m.mask) * m.magic) >> m.shift
+}
+
+func rookAttacks(index int, occupied bitboard) bitboard {
+	entry := &rookMagics[index]
+	return entry.attacks[entry.index(occupied)]
+}
+
+func bishopAttacks(index int, occupied bitboard) bitboard {
+	entry := &bishopMagics[index]
+	return entry.attacks[entry.index(occupied)]
+}
@@ -0,0 +1,88 @@
+package chess
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// The number of Chess960 starting positions
+const Chess960Positions = 960
+
+var standardBackRank = [8]piece{rook, knight, bishop, queen, king, bishop, knight, rook}
+
+// Placements of the two knights among the five squares left once the bishops and queen are placed, in the
+// order of Scharnagl's numbering
+var chess960KnightSquares = [10][2]int{{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+
+// Returns the back rank of a Chess960 starting position, numbered from 0 to 959 as by Scharnagl.  The bishops
+// go on opposite colors, then the queen and knights on the squares left, and the king between the rooks.
+func chess960BackRank(position int) [8]piece {
+	var backRank [8]piece
+
+	position, lightBishop := position/4, position%4
+	backRank[2*lightBishop+1] = bishop
+	position, darkBishop := position/4, position%4
+	backRank[2*darkBishop] = bishop
+
+	// Places pieceType on the nth empty square
+	placeOnEmpty := func(n int, pieceType piece) {
+		for file := range backRank {
+			if backRank[file] != empty {
+				continue
+			}
+			if n == 0 {
+				backRank[file] = pieceType
+				return
+			}
+			n--
+		}
+	}
+
+	position, queenSquare := position/6, position%6
+	placeOnEmpty(queenSquare, queen)
+	knights := chess960KnightSquares[position]
+	// Placing the first knight takes away one of the empty squares before the second
+	placeOnEmpty(knights[1], knight)
+	placeOnEmpty(knights[0], knight)
+	placeOnEmpty(0, rook)
+	placeOnEmpty(0, king)
+	placeOnEmpty(0, rook)
+
+	return backRank
+}
+
+// Sets up a Chess960 starting position, numbered from 0 to 959
+func (cb *chessBoard) initChess960(position int) error {
+	if position < 0 || position >= Chess960Positions {
+		return fmt.Errorf("Chess960 positions are numbered from 0 to %d, got %d", Chess960Positions-1, position)
+	}
+	cb.setUpStartingPosition(chess960BackRank(position))
+	cb.chess960 = true
+	return nil
+}
+
+// Parses the number of a Chess960 starting position, or "random" for one picked at random
+func ParseChess960Position(text string) (int, error) {
+	if text == "random" {
+		return rand.Intn(Chess960Positions), nil
+	}
+	position, err := strconv.Atoi(text)
+	if err != nil || position < 0 || position >= Chess960Positions {
+		return 0, fmt.Errorf("Chess960 position must be a number from 0 to %d or random, got %q", Chess960Positions-1, text)
+	}
+	return position, nil
+}
+
+// The rank a side's pieces start on
+func homeRank(color pieceColor) int {
+	if color == black {
+		return 7
+	}
+	return 0
+}
+
+// The squares of a rank
+func rankSquares(rank int) bitboard {
+	return bitboard(0xff) << (8 * rank)
+}
@@ -0,0 +1,153 @@
+package chess
+
+import "testing"
+
+func TestChess960StartingPositions(t *testing.T) {
+	tests := []struct {
+		position int
+		fen      string
+	}{
+		{0, "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"},
+		{518, StartingFEN},
+		{959, "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1"},
+	}
+	for _, test := range tests {
+		game, err := NewChess960Game(test.position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.FEN() != test.fen || !game.Chess960() {
+			t.Errorf("position %d is %q, want %q", test.position, game.FEN(), test.fen)
+		}
+	}
+	if _, err := NewChess960Game(Chess960Positions); err == nil {
+		t.Errorf("started position %d", Chess960Positions)
+	}
+
+	// Every position has its bishops on opposite colors and its king between its rooks
+	for position := range Chess960Positions {
+		backRank := chess960BackRank(position)
+		var bishopFiles, rookFiles []int
+		kingFile := -1
+		for file, pieceType := range backRank {
+			switch pieceType {
+			case bishop:
+				bishopFiles = append(bishopFiles, file)
+			case rook:
+				rookFiles = append(rookFiles, file)
+			case king:
+				kingFile = file
+			}
+		}
+		if len(bishopFiles) != 2 || (bishopFiles[0]+bishopFiles[1])%2 == 0 ||
+			len(rookFiles) != 2 || kingFile < rookFiles[0] || kingFile > rookFiles[1] {
+			t.Fatalf("position %d has back rank %v", position, backRank)
+		}
+	}
+}
+
+func TestCastlingRightsFEN(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1",
+		// X-FEN names an inner rook by its file
+		"rr2k2r/8/8/8/8/8/8/RR2K1RR w GBkq - 0 1",
+		// Shredder-FEN names every rook by its file
+		"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+		"4k3/8/8/8/8/8/8/R3K2R w HA - 0 1",
+	}
+	for _, fen := range fens {
+		var board chessBoard
+		if err := board.loadFEN(fen); err != nil {
+			t.Errorf("%q: %v", fen, err)
+			continue
+		}
+		if got := board.toFEN(); got != fen {
+			t.Errorf("%q written back as %q", fen, got)
+		}
+	}
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/4K3 w K - 0 1",
+		"4k3/8/8/8/8/8/4K3/R6R w Q - 0 1",
+		"4k3/8/8/8/8/8/8/RR2K3 w AB - 0 1",
+		"4k3/8/8/8/8/8/8/R3K2R w KK - 0 1",
+	} {
+		var board chessBoard
+		if err := board.loadFEN(fen); err == nil {
+			t.Errorf("loaded %q", fen)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	// The king on f1 castles kingside to g1, which it could also step to
+	game, err := NewGameFromFEN("4k3/8/8/8/8/8/8/R4K1R w KQ - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !game.Chess960() {
+		t.Fatal("castling with the king on f1 is not Chess960")
+	}
+	f1, g1, h1 := SquareAt(5, 0), SquareAt(6, 0), SquareAt(7, 0)
+	if san, err := game.SAN(Move{From: f1, To: h1}); err != nil || san != "O-O" {
+		t.Errorf("f1h1 is %q, %v, want O-O", san, err)
+	}
+	if san, err := game.SAN(Move{From: f1, To: g1}); err != nil || san != "Kg1" {
+		t.Errorf("f1g1 is %q, %v, want Kg1", san, err)
+	}
+
+	playMoves(t, game, "O-O-O")
+	if want := "4k3/8/8/8/8/8/8/2KR3R b - - 1 1"; game.FEN() != want {
+		t.Errorf("after O-O-O the position is %q, want %q", game.FEN(), want)
+	}
+	if moves := game.Moves(); moves[0].String() != "f1a1" {
+		t.Errorf("O-O-O is written %s, want f1a1", moves[0])
+	}
+	game.Undo()
+	if want := "4k3/8/8/8/8/8/8/R4K1R w KQ - 0 1"; game.FEN() != want {
+		t.Errorf("after undoing O-O-O the position is %q, want %q", game.FEN(), want)
+	}
+
+	// Standard games write castling as the king's move of two squares, but take the king onto its rook too
+	game = NewGame()
+	playMoves(t, game, "e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5", "e1h1")
+	if moves := game.Moves(); moves[len(moves)-1].String() != "e1g1" {
+		t.Errorf("O-O is written %s, want e1g1", moves[len(moves)-1])
+	}
+}
+
+func TestChess960CastlingLegality(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		san   string
+		after string // "" if the castling is illegal
+	}{
+		{"onto the queenside rook's square", "8/8/8/4B2b/6nN/8/5P2/2R1K2k w Q - 0 1", "O-O-O", "8/8/8/4B2b/6nN/8/5P2/2KR3k b - - 1 1"},
+		{"past the rook beside the king", "2r5/8/8/8/8/8/6PP/k2KR3 w K - 0 1", "O-O", "2r5/8/8/8/8/8/6PP/k4RK1 b - - 1 1"},
+		// The rook that moves no longer blocks the queen from the king's square
+		{"away from a shielding rook", "4r3/3k4/8/8/8/8/6PP/qR1K1R2 w KQ - 0 1", "O-O-O", ""},
+		{"through an attacked square", "4r3/3k4/8/8/8/8/6PP/qR1K1R2 w KQ - 0 1", "O-O", ""},
+	}
+	for _, test := range tests {
+		game, err := NewGameFromFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		move, err := game.ParseMove(test.san)
+		if test.after == "" {
+			if err == nil {
+				t.Errorf("%s: %s is legal in %q", test.name, test.san, test.fen)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s in %q: %v", test.name, test.san, test.fen, err)
+			continue
+		}
+		if err := game.Play(move); err != nil || game.FEN() != test.after {
+			t.Errorf("%s: after %s the position is %q, %v, want %q", test.name, test.san, game.FEN(), err, test.after)
+		}
+	}
+}
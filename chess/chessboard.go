@@ -0,0 +1,293 @@
+package chess
+
+import "fmt"
+
+type chessBoard struct {
+	// Board State
+	board           [8][8]chessPiece
+	pieceBitboards  [3][7]bitboard // The squares of each piece, by color then piece type.  Kept in sync with board by setPiece.
+	colorBitboards  [3]bitboard    // The squares of each color's pieces
+	enpassantSquare vector2
+	// Rooks that can still castle.  A rook loses the right when it moves or is captured, and both of a side's
+	// rooks lose it when their king moves.
+	castlingRooks bitboard
+	// Whether the game is Chess960, which changes how castling moves and rights are written
+	chess960 bool
+	// Whether castling rights are written in FEN by rook file alone, as Shredder-FEN does, rather than as X-FEN
+	shredderCastling bool
+	// Move Counters
+	sideToMove     pieceColor
+	halfmoveClock  int
+	fullmoveNumber int
+	// Zobrist hash of the position, updated incrementally by setPiece and movePiece
+	hash uint64
+	// Hashes of every position reached, for repetition detection.  Boards without a history, such as the
+	// lookahead copies made by deepCopy, don't record positions.
+	positionHistory []uint64
+}
+
+// Sets up the standard starting position
+func (cb *chessBoard) init() {
+	cb.setUpStartingPosition(standardBackRank)
+}
+
+// Sets up the starting position with the given pieces, from the a-file to the h-file, on both back ranks.
+// Every rook on the back ranks can castle.
+func (cb *chessBoard) setUpStartingPosition(backRank [8]piece) {
+	*cb = chessBoard{}
+	for file, pieceType := range backRank {
+		cb.setPiece(vector2{file, 0}, chessPiece{pieceType, white})
+		cb.setPiece(vector2{file, 1}, chessPiece{pawn, white})
+		cb.setPiece(vector2{file, 6}, chessPiece{pawn, black})
+		cb.setPiece(vector2{file, 7}, chessPiece{pieceType, black})
+	}
+	cb.castlingRooks = cb.pieceBitboards[white][rook] | cb.pieceBitboards[black][rook]
+
+	cb.enpassantSquare = nilSquare
+	cb.sideToMove = white
+	cb.halfmoveClock = 0
+	cb.fullmoveNumber = 1
+
+	cb.hash = cb.computeHash()
+	cb.positionHistory = []uint64{cb.hash}
+}
+
+func (cb *chessBoard) deepCopy() chessBoard {
+	newBoard := *cb
+	newBoard.positionHistory = nil
+
+	return newBoard
+}
+
+func (cb *chessBoard) getPiece(square vector2) chessPiece {
+	return cb.board[square.x][square.y]
+}
+
+func (cb *chessBoard) getKingSquare(playerColor pieceColor) vector2 {
+	return indexToSquare(cb.kingIndex(playerColor))
+}
+
+func (cb *chessBoard) kingIndex(playerColor pieceColor) int {
+	kings := cb.pieceBitboards[playerColor][king]
+	if kings == 0 {
+		panic(fmt.Sprintf("No %+v king found in board!", playerColor))
+	}
+	return kings.first()
+}
+
+func (cb *chessBoard) setPiece(square vector2, piece chessPiece) {
+	squareBit := squareBitboard(squareIndex(square))
+	if oldPiece := cb.board[square.x][square.y]; oldPiece != emptyPiece {
+		cb.pieceBitboards[oldPiece.color][oldPiece.pieceType] &^= squareBit
+		cb.colorBitboards[oldPiece.color] &^= squareBit
+	}
+
+	cb.hash ^= zobristPieceKey(cb.board[square.x][square.y], square) ^ zobristPieceKey(piece, square)
+	cb.board[square.x][square.y] = piece
+	if piece != emptyPiece {
+		cb.pieceBitboards[piece.color][piece.pieceType] |= squareBit
+		cb.colorBitboards[piece.color] |= squareBit
+	}
+}
+
+// Takes away the castling rights lost by a move: those of a rook moving or captured, or of both rooks when
+// the king moves
+func (cb *chessBoard) updateCastlingRights(move chessMove) {
+	cb.castlingRooks &^= squareBitboard(squareIndex(move.from)) | squareBitboard(squareIndex(move.to))
+	if move.movedPiece.pieceType == king {
+		cb.castlingRooks &^= rankSquares(homeRank(move.movedPiece.color))
+	}
+}
+
+// The squares the king and rook end up on after castling with the rook on rookSquare
+func castlingTargets(kingSquare vector2, rookSquare vector2) (vector2, vector2) {
+	if rookSquare.x > kingSquare.x {
+		return vector2{6, kingSquare.y}, vector2{5, kingSquare.y}
+	}
+	return vector2{2, kingSquare.y}, vector2{3, kingSquare.y}
+}
+
+// Board state that cannot be recovered from a move alone, captured by movePiece so unmakeMove can restore it
+type moveUndoState struct {
+	move            chessMove
+	enpassantSquare vector2
+	castlingRooks   bitboard
+	halfmoveClock   int
+	hash            uint64
+}
+
+// Expects given move to be legal.  Returns the state needed to take the move back with unmakeMove.
+func (cb *chessBoard) movePiece(move chessMove) moveUndoState {
+	piece := move.movedPiece
+	undo := moveUndoState{
+		move:            move,
+		enpassantSquare: cb.enpassantSquare,
+		castlingRooks:   cb.castlingRooks,
+		halfmoveClock:   cb.halfmoveClock,
+		hash:            cb.hash,
+	}
+
+	// Take the castling rights, en passant file and side to move out of the hash, to be put back once updated
+	cb.hash ^= cb.zobristStateKey()
+
+	if move.is(castlingFlag) {
+		// The king moves onto its rook, so both are lifted off before being put on their squares, which
+		// may be each other's
+		kingTarget, rookTarget := castlingTargets(move.from, move.to)
+		cb.setPiece(move.from, emptyPiece)
+		cb.setPiece(move.to, emptyPiece)
+		cb.setPiece(kingTarget, piece)
+		cb.setPiece(rookTarget, chessPiece{rook, piece.color})
+	} else {
+		cb.setPiece(move.to, piece)
+		cb.setPiece(move.from, emptyPiece)
+	}
+
+	if move.is(promotionFlag) {
+		cb.setPiece(move.to, chessPiece{move.promotion, piece.color})
+	}
+
+	// Remove the pawn captured en passant, which sits beside the moving pawn rather than on the target square
+	if move.is(enPassantFlag) {
+		cb.setPiece(vector2{move.to.x, move.from.y}, emptyPiece)
+	}
+
+	// Updates Enpassant state if applicable
+	if move.is(doublePawnPushFlag) {
+		cb.enpassantSquare = vector2{move.from.x, (move.from.y + move.to.y) / 2}
+	} else {
+		cb.enpassantSquare = nilSquare
+	}
+
+	cb.updateCastlingRights(move)
+
+	// Update move counters
+	if piece.pieceType == pawn || move.is(captureFlag) {
+		cb.halfmoveClock = 0
+	} else {
+		cb.halfmoveClock++
+	}
+	if piece.color == black {
+		cb.fullmoveNumber++
+	}
+	cb.sideToMove = piece.color.oppositeColor()
+
+	cb.hash ^= cb.zobristStateKey()
+	if zobristDebug {
+		cb.verifyHash()
+	}
+
+	if cb.positionHistory != nil {
+		cb.positionHistory = append(cb.positionHistory, cb.hash)
+	}
+
+	return undo
+}
+
+// Takes back the move that produced undo.  Moves must be unmade in the reverse order they were made.
+func (cb *chessBoard) unmakeMove(undo moveUndoState) {
+	move := undo.move
+
+	switch {
+	case move.is(castlingFlag):
+		kingTarget, rookTarget := castlingTargets(move.from, move.to)
+		cb.setPiece(kingTarget, emptyPiece)
+		cb.setPiece(rookTarget, emptyPiece)
+		cb.setPiece(move.from, move.movedPiece)
+		cb.setPiece(move.to, chessPiece{rook, move.movedPiece.color})
+	case move.is(enPassantFlag):
+		cb.setPiece(move.from, move.movedPiece)
+		cb.setPiece(move.to, emptyPiece)
+		cb.setPiece(vector2{move.to.x, move.from.y}, move.capturedPiece)
+	default:
+		// Restores the pawn for promotions as well
+		cb.setPiece(move.from, move.movedPiece)
+		cb.setPiece(move.to, move.capturedPiece)
+	}
+
+	cb.enpassantSquare = undo.enpassantSquare
+	cb.castlingRooks = undo.castlingRooks
+	cb.halfmoveClock = undo.halfmoveClock
+	if move.movedPiece.color == black {
+		cb.fullmoveNumber--
+	}
+	cb.sideToMove = move.movedPiece.color
+	cb.hash = undo.hash
+	if zobristDebug {
+		cb.verifyHash()
+	}
+
+	if len(cb.positionHistory) > 1 {
+		cb.positionHistory = cb.positionHistory[:len(cb.positionHistory)-1]
+	}
+}
+
+func (cb *chessBoard) isEmpty(square vector2) bool {
+	return cb.getPiece(square) == emptyPiece
+}
+
+func (cb *chessBoard) inBoard(square vector2) bool {
+	return square.x >= 0 &&
+		square.x < 8 &&
+		square.y >= 0 &&
+		square.y < 8
+}
+
+func (cb *chessBoard) playerInCheck(playerColor pieceColor) bool {
+	kingIndex := cb.kingIndex(playerColor)
+
+	return cb.attackersOf(kingIndex, playerColor.oppositeColor(), cb.occupied()) != 0
+}
+
+func (cb *chessBoard) playerInCheckMate(playerColor pieceColor) bool {
+
+	if !cb.playerInCheck(playerColor) {
+		return false
+	}
+
+	return len(cb.getAllValidMovesForPlayer(playerColor)) == 0
+}
+
+// Reports whether the side to move is not in check but has no legal moves
+func (cb *chessBoard) isStalemate() bool {
+	if cb.playerInCheck(cb.sideToMove) {
+		return false
+	}
+	return len(cb.getAllValidMovesForPlayer(cb.sideToMove)) == 0
+}
+
+func (cb *chessBoard) getAllValidMovesForPlayer(playerColor pieceColor) []chessMove {
+	return cb.generateLegalMoves(playerColor, nil)
+}
+
+func (cb *chessBoard) getValidMoves(square vector2) []chessMove {
+	chessPiece := cb.getPiece(square)
+	if chessPiece == emptyPiece {
+		return nil
+	}
+
+	var validMoves []chessMove
+	for _, move := range cb.generateLegalMoves(chessPiece.color, nil) {
+		if move.from == square {
+			validMoves = append(validMoves, move)
+		}
+	}
+	return validMoves
+}
+
+func (cb *chessBoard) isSquareAttacked(square vector2, attackingColor pieceColor) bool {
+	return cb.attackersOf(squareIndex(square), attackingColor, cb.occupied()) != 0
+}
+
+// Reports whether move, matched by its squares and promotion piece, is legal for the piece on its from square
+func (cb *chessBoard) isValidMove(move chessMove) bool {
+	if !cb.inBoard(move.from) || !cb.inBoard(move.to) {
+		return false
+	}
+	for _, validMove := range cb.getValidMoves(move.from) {
+		if validMove.to == move.to && validMove.promotion == move.promotion {
+			return true
+		}
+	}
+	return false
+}
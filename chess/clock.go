@@ -0,0 +1,195 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a period of a time control adds time for each move
+type TimeBonus int
+
+const (
+	NoBonus        TimeBonus = iota
+	Increment                // Fischer increment: the bonus is added after every move
+	BronsteinDelay           // The time used on a move is given back after it, up to the bonus
+	SimpleDelay              // The clock only starts running once the bonus has passed on each move
+)
+
+// Part of a time control: a number of moves to be made in a time
+type TimePeriod struct {
+	Moves     int           // Moves to be made in the period, 0 for the rest of the game
+	Time      time.Duration // Added to the clock when the period starts
+	Bonus     time.Duration // Time each move earns, as given by BonusKind
+	BonusKind TimeBonus
+}
+
+// The periods of a game's time control, in order.  A last period with a number of moves repeats.
+type TimeControl []TimePeriod
+
+// Parses a time control of comma separated periods.  Each is written [moves/]minutes, optionally followed by
+// +seconds for an increment, dseconds for a simple delay or bseconds for a Bronstein delay.  "5" is five
+// minutes sudden death, "3+2" three minutes with a two second increment, and "40/90+30,30+30" ninety minutes
+// for the first forty moves then thirty minutes for the rest, with thirty seconds added per move throughout.
+// Neither the minutes nor the seconds may be more than a day.
+func ParseTimeControl(text string) (TimeControl, error) {
+	var control TimeControl
+	for _, periodText := range strings.Split(text, ",") {
+		period, err := parseTimePeriod(strings.TrimSpace(periodText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time control %q: %w", text, err)
+		}
+		control = append(control, period)
+	}
+	for _, period := range control[:len(control)-1] {
+		if period.Moves == 0 {
+			return nil, fmt.Errorf("invalid time control %q: only the last period can last for the rest of the game", text)
+		}
+	}
+	return control, nil
+}
+
+// The most time a period or its bonus may give.  Longer times, and ones that aren't finite, would overflow a
+// time.Duration.
+const maxTimePeriod = 24 * time.Hour
+
+func parseTimePeriod(text string) (TimePeriod, error) {
+	var period TimePeriod
+
+	if moves, rest, found := strings.Cut(text, "/"); found {
+		count, err := strconv.Atoi(moves)
+		if err != nil || count <= 0 {
+			return TimePeriod{}, fmt.Errorf("%q is not a number of moves", moves)
+		}
+		period.Moves = count
+		text = rest
+	}
+
+	minutes := text
+	if i := strings.IndexAny(text, "+db"); i != -1 {
+		minutes = text[:i]
+		period.BonusKind = map[byte]TimeBonus{'+': Increment, 'd': SimpleDelay, 'b': BronsteinDelay}[text[i]]
+		seconds, err := strconv.ParseFloat(text[i+1:], 64)
+		if err != nil || !(seconds >= 0) || seconds > maxTimePeriod.Seconds() {
+			return TimePeriod{}, fmt.Errorf("%q is not a number of seconds up to %v", text[i+1:], maxTimePeriod)
+		}
+		period.Bonus = time.Duration(seconds * float64(time.Second))
+	}
+
+	count, err := strconv.ParseFloat(minutes, 64)
+	if err != nil || !(count > 0) || count > maxTimePeriod.Minutes() {
+		return TimePeriod{}, fmt.Errorf("%q is not a number of minutes up to %v", minutes, maxTimePeriod)
+	}
+	period.Time = time.Duration(count * float64(time.Minute))
+	return period, nil
+}
+
+// Writes the time control in the form ParseTimeControl reads
+func (tc TimeControl) String() string {
+	periods := make([]string, len(tc))
+	for i, period := range tc {
+		if period.Moves != 0 {
+			periods[i] = fmt.Sprintf("%d/", period.Moves)
+		}
+		periods[i] += strconv.FormatFloat(period.Time.Minutes(), 'f', -1, 64)
+		if period.BonusKind != NoBonus {
+			periods[i] += map[TimeBonus]string{Increment: "+", SimpleDelay: "d", BronsteinDelay: "b"}[period.BonusKind]
+			periods[i] += strconv.FormatFloat(period.Bonus.Seconds(), 'f', -1, 64)
+		}
+	}
+	return strings.Join(periods, ",")
+}
+
+// A chess clock for both sides.  Its methods take the current time so that it can be driven by any clock.
+type Clock struct {
+	control   TimeControl
+	remaining [3]time.Duration // By color, the time left when the side's turn started
+	period    [3]int           // By color, the period of the control the side is in
+	moves     [3]int           // By color, the moves the side has made in its period
+	running   Color            // The side whose time is running, zero when stopped
+	turnStart time.Time
+}
+
+// A stopped clock with each side given the time of the control's first period
+func NewClock(control TimeControl) *Clock {
+	c := &Clock{control: control}
+	c.remaining[White] = control[0].Time
+	c.remaining[Black] = control[0].Time
+	return c
+}
+
+// Starts side's time running
+func (c *Clock) Start(side Color, now time.Time) {
+	c.running = side
+	c.turnStart = now
+}
+
+// The side whose time is running, or zero when the clock is stopped
+func (c *Clock) Running() Color {
+	return c.running
+}
+
+// The time side has left, never less than zero
+func (c *Clock) Remaining(side Color, now time.Time) time.Duration {
+	remaining := c.remaining[side]
+	if side == c.running {
+		remaining -= c.charged(now)
+	}
+	return max(remaining, 0)
+}
+
+// The time the running side's turn has cost so far
+func (c *Clock) charged(now time.Time) time.Duration {
+	used := now.Sub(c.turnStart)
+	if period := c.control[c.period[c.running]]; period.BonusKind == SimpleDelay {
+		used = max(used-period.Bonus, 0)
+	}
+	return used
+}
+
+// The running side if its time has run out, otherwise zero
+func (c *Clock) Flagged(now time.Time) Color {
+	if c.running != 0 && c.Remaining(c.running, now) == 0 {
+		return c.running
+	}
+	return 0
+}
+
+// Ends the running side's turn once it has moved, adding its bonus and the time of any period it has completed,
+// and starts its opponent's time
+func (c *Clock) Press(now time.Time) {
+	side := c.running
+	if side == 0 {
+		return
+	}
+
+	period := c.control[c.period[side]]
+	used := now.Sub(c.turnStart)
+	c.remaining[side] -= c.charged(now)
+	switch period.BonusKind {
+	case Increment:
+		c.remaining[side] += period.Bonus
+	case BronsteinDelay:
+		c.remaining[side] += min(used, period.Bonus)
+	}
+
+	c.moves[side]++
+	if period.Moves != 0 && c.moves[side] == period.Moves {
+		// The last period repeats
+		c.period[side] = min(c.period[side]+1, len(c.control)-1)
+		c.moves[side] = 0
+		c.remaining[side] += c.control[c.period[side]].Time
+	}
+
+	c.Start(side.Opponent(), now)
+}
+
+// Stops the clock, charging the running side for its turn so far
+func (c *Clock) Stop(now time.Time) {
+	if c.running == 0 {
+		return
+	}
+	c.remaining[c.running] -= c.charged(now)
+	c.running = 0
+}
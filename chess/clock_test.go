@@ -0,0 +1,161 @@
+package chess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		text string
+		want TimeControl
+	}{
+		{"5", TimeControl{{Time: 5 * time.Minute}}},
+		{"3+2", TimeControl{{Time: 3 * time.Minute, Bonus: 2 * time.Second, BonusKind: Increment}}},
+		{"0.5d3", TimeControl{{Time: 30 * time.Second, Bonus: 3 * time.Second, BonusKind: SimpleDelay}}},
+		{"5b2.5", TimeControl{{Time: 5 * time.Minute, Bonus: 2500 * time.Millisecond, BonusKind: BronsteinDelay}}},
+		{"40/90+30,30+30", TimeControl{
+			{Moves: 40, Time: 90 * time.Minute, Bonus: 30 * time.Second, BonusKind: Increment},
+			{Time: 30 * time.Minute, Bonus: 30 * time.Second, BonusKind: Increment},
+		}},
+	}
+	for _, test := range tests {
+		control, err := ParseTimeControl(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+			continue
+		}
+		if control.String() != test.want.String() || len(control) != len(test.want) || control[0] != test.want[0] {
+			t.Errorf("%q parsed as %+v, want %+v", test.text, control, test.want)
+		}
+		if control.String() != test.text {
+			t.Errorf("%q written back as %q", test.text, control.String())
+		}
+	}
+
+	for _, text := range []string{
+		"", "x", "5+", "0", "-3", "40/", "30,40/90",
+		"NaN", "Inf", "-Inf", "1e300", "1441", "5+NaN", "5+Inf", "5+1e300", "5d86401",
+	} {
+		if _, err := ParseTimeControl(text); err == nil {
+			t.Errorf("%q parsed", text)
+		}
+	}
+}
+
+// Plays moves taking the given times on a clock started at start, returning the time of the last move
+func pressAfter(clock *Clock, start time.Time, moveTimes ...time.Duration) time.Time {
+	now := start
+	for _, moveTime := range moveTimes {
+		now = now.Add(moveTime)
+		clock.Press(now)
+	}
+	return now
+}
+
+func TestClockBonuses(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		control   string
+		moveTimes []time.Duration // Alternating white and black
+		white     time.Duration
+		black     time.Duration
+	}{
+		{"1", []time.Duration{10 * time.Second, 5 * time.Second}, 50 * time.Second, 55 * time.Second},
+		{"1+2", []time.Duration{10 * time.Second, 1 * time.Second}, 52 * time.Second, 61 * time.Second},
+		{"1b2", []time.Duration{10 * time.Second, 1 * time.Second}, 52 * time.Second, 60 * time.Second},
+		{"1d2", []time.Duration{10 * time.Second, 1 * time.Second}, 52 * time.Second, 60 * time.Second},
+	}
+	for _, test := range tests {
+		control, _ := ParseTimeControl(test.control)
+		clock := NewClock(control)
+		clock.Start(White, start)
+		now := pressAfter(clock, start, test.moveTimes...)
+		if white, black := clock.Remaining(White, now), clock.Remaining(Black, now); white != test.white || black != test.black {
+			t.Errorf("%s: white has %v and black %v, want %v and %v", test.control, white, black, test.white, test.black)
+		}
+	}
+
+	// The simple delay passes before the clock runs
+	control, _ := ParseTimeControl("1d2")
+	clock := NewClock(control)
+	clock.Start(White, start)
+	if remaining := clock.Remaining(White, start.Add(time.Second)); remaining != time.Minute {
+		t.Errorf("white has %v during the delay, want %v", remaining, time.Minute)
+	}
+}
+
+func TestClockPeriods(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	control, _ := ParseTimeControl("2/1,1/2")
+	clock := NewClock(control)
+	clock.Start(White, start)
+
+	// White's second move completes the first period and earns the second's two minutes
+	now := pressAfter(clock, start, 10*time.Second, time.Second, 10*time.Second)
+	if remaining := clock.Remaining(White, now); remaining != 40*time.Second+2*time.Minute {
+		t.Errorf("white has %v after the first period, want %v", remaining, 40*time.Second+2*time.Minute)
+	}
+	// The last period repeats
+	now = pressAfter(clock, now, time.Second, 20*time.Second)
+	if remaining := clock.Remaining(White, now); remaining != 20*time.Second+4*time.Minute {
+		t.Errorf("white has %v after repeating the last period, want %v", remaining, 20*time.Second+4*time.Minute)
+	}
+}
+
+func TestClockFlag(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	control, _ := ParseTimeControl("1")
+	clock := NewClock(control)
+	clock.Start(White, start)
+
+	if side := clock.Flagged(start.Add(59 * time.Second)); side != 0 {
+		t.Errorf("%v flagged with a second left", side)
+	}
+	if side := clock.Flagged(start.Add(time.Minute)); side != White {
+		t.Errorf("flagged side = %v, want white", side)
+	}
+	clock.Stop(start.Add(2 * time.Minute))
+	if side := clock.Flagged(start.Add(3 * time.Minute)); side != 0 {
+		t.Errorf("%v flagged on a stopped clock", side)
+	}
+}
+
+func TestGameTimeOut(t *testing.T) {
+	tests := []struct {
+		fen  string
+		side Color
+		want Result
+	}{
+		{StartingFEN, White, BlackWon},
+		// The opponent has only a king
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", White, Drawn},
+		{"4k3/8/8/8/8/8/4P3/4KN2 b - - 0 1", Black, WhiteWon},
+		// A lone knight can mate with the help of the flagged side's own pieces
+		{"4k3/4p3/8/8/8/8/8/4KN2 w - - 0 1", Black, WhiteWon},
+		{"4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", Black, WhiteWon},
+		// A bishop can mate with the help of an opposing bishop on squares of the other color
+		{"4k3/8/4b3/8/8/8/8/2B1K3 w - - 0 1", White, BlackWon},
+	}
+	for _, test := range tests {
+		game, err := NewGameFromFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.TimeOut(test.side)
+		if outcome := game.Outcome(); outcome.Result() != test.want || outcome.Termination() != Timeout {
+			t.Errorf("%s: %v out of time gives %v by %v, want %v", test.fen, test.side, outcome.Result(), outcome.Termination(), test.want)
+		}
+	}
+
+	// With bishops all on squares of one color, neither side can mate whoever runs out of time.  The game is
+	// drawn by insufficient material already.
+	game, err := NewGameFromFEN("4k3/8/3b4/8/8/8/8/2B1K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.TimeOut(White)
+	if game.Result() != Drawn || game.board.canCheckmate(white) || game.board.canCheckmate(black) {
+		t.Errorf("a side can mate with bishops on squares of one color, out of time gives %v", game.Outcome())
+	}
+}
@@ -0,0 +1,159 @@
+package chess
+
+const (
+	fiftyMoveRulePlies       = 100
+	seventyFiveMoveRulePlies = 150
+)
+
+// Reports whether the side to move can legally capture en passant
+func (cb *chessBoard) enPassantCaptureAvailable() bool {
+	if cb.enpassantSquare == nilSquare {
+		return false
+	}
+
+	// Pawns that can capture onto the target square are those an opposing pawn there would attack
+	target := squareIndex(cb.enpassantSquare)
+	capturers := pawnAttacks[cb.sideToMove.oppositeColor()][target] & cb.pieceBitboards[cb.sideToMove][pawn]
+	kingIndex := cb.kingIndex(cb.sideToMove)
+	for capturers != 0 {
+		if cb.enPassantIsLegal(cb.sideToMove, kingIndex, capturers.popFirst(), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns how many times the current position has occurred, including now
+func (cb *chessBoard) repetitionCount() int {
+	if len(cb.positionHistory) == 0 {
+		return 1
+	}
+
+	currentHash := cb.positionHistory[len(cb.positionHistory)-1]
+	count := 0
+	for _, hash := range cb.positionHistory {
+		if hash == currentHash {
+			count++
+		}
+	}
+	return count
+}
+
+// Reports whether neither side can possibly checkmate: K vs K, K+B vs K, K+N vs K, or kings with any
+// number of bishops that all stand on squares of the same color
+func (cb *chessBoard) hasInsufficientMaterial() bool {
+	var minorPieces []chessPiece
+	bishopSquareColors := map[int]bool{}
+
+	for x := range 8 {
+		for y := range 8 {
+			piece := cb.getPiece(vector2{x, y})
+			switch piece.pieceType {
+			case empty, king:
+				continue
+			case bishop:
+				bishopSquareColors[(x+y)%2] = true
+				minorPieces = append(minorPieces, piece)
+			case knight:
+				minorPieces = append(minorPieces, piece)
+			default:
+				return false
+			}
+		}
+	}
+
+	if len(minorPieces) <= 1 {
+		return true
+	}
+
+	for _, minorPiece := range minorPieces {
+		if minorPiece.pieceType != bishop {
+			return false
+		}
+	}
+	return len(bishopSquareColors) == 1
+}
+
+// Reports whether color could checkmate its opponent by some series of legal moves, with the opponent's help
+// if need be.  A side with only its king can't, nor can a lone knight or bishops that all stand on squares of
+// the same color against a bare king, nor anyone when the only pieces besides the kings are bishops on squares
+// of one color.
+func (cb *chessBoard) canCheckmate(color pieceColor) bool {
+	var minorPieces []chessPiece
+	bishopSquareColors := map[int]bool{}
+	opponentHasPieces := false
+	// Of both sides' pieces besides the kings
+	onlyBishops := true
+	allBishopSquareColors := map[int]bool{}
+
+	for x := range 8 {
+		for y := range 8 {
+			piece := cb.getPiece(vector2{x, y})
+			if piece.pieceType == bishop {
+				allBishopSquareColors[(x+y)%2] = true
+			} else if piece.pieceType != empty && piece.pieceType != king {
+				onlyBishops = false
+			}
+			switch {
+			case piece.pieceType == empty || piece.pieceType == king:
+			case piece.color != color:
+				opponentHasPieces = true
+			case piece.pieceType == bishop:
+				bishopSquareColors[(x+y)%2] = true
+				minorPieces = append(minorPieces, piece)
+			case piece.pieceType == knight:
+				minorPieces = append(minorPieces, piece)
+			default:
+				return true
+			}
+		}
+	}
+
+	if len(minorPieces) == 0 {
+		return false
+	}
+	if onlyBishops && len(allBishopSquareColors) == 1 {
+		// Neither king can be hemmed in on a square of the other color, and the bishops never attack one
+		return false
+	}
+	if opponentHasPieces {
+		// The opponent's own pieces can hem its king in
+		return true
+	}
+	if len(minorPieces) == 1 {
+		return false
+	}
+	for _, minorPiece := range minorPieces {
+		if minorPiece.pieceType != bishop {
+			return true
+		}
+	}
+	return len(bishopSquareColors) == 2
+}
+
+// Returns why the position is drawn without a player needing to claim it, or notTerminated if it is not
+func (cb *chessBoard) automaticDrawTermination() Termination {
+	switch {
+	case cb.isStalemate():
+		return Stalemate
+	case cb.hasInsufficientMaterial():
+		return InsufficientMaterial
+	case cb.repetitionCount() >= 5:
+		return Repetition
+	case cb.halfmoveClock >= seventyFiveMoveRulePlies && !cb.playerInCheckMate(cb.sideToMove):
+		return FiftyMoveRule
+	}
+	return NotTerminated
+}
+
+// Returns why the side to move may claim a draw by threefold repetition or the fifty-move rule, or
+// notTerminated if it may not
+func (cb *chessBoard) claimableDrawTermination() Termination {
+	switch {
+	case cb.repetitionCount() >= 3:
+		return Repetition
+	case cb.halfmoveClock >= fiftyMoveRulePlies:
+		return FiftyMoveRule
+	}
+	return NotTerminated
+}
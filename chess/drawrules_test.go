@@ -0,0 +1,100 @@
+package chess
+
+import "testing"
+
+func TestRepetition(t *testing.T) {
+	game := NewGame()
+	shuffle := []string{"Nf3", "Nf6", "Ng1", "Ng8"}
+
+	// The starting position occurs a second time, then a third, which may be claimed
+	playMoves(t, game, shuffle...)
+	if termination := game.ClaimableDraw(); termination != NotTerminated {
+		t.Errorf("claimable draw %v after the position occurred twice", termination)
+	}
+	playMoves(t, game, shuffle...)
+	if termination := game.ClaimableDraw(); termination != Repetition || game.Outcome().IsOver() {
+		t.Errorf("claimable draw %v and outcome %v after threefold repetition", termination, game.Outcome())
+	}
+
+	// Unclaimed, the game goes on until the position occurs a fifth time
+	playMoves(t, game, shuffle...)
+	if game.Outcome().IsOver() {
+		t.Errorf("game over after the position occurred four times: %v", game.Outcome())
+	}
+	playMoves(t, game, shuffle...)
+	if outcome := game.Outcome(); outcome.Result() != Drawn || outcome.Termination() != Repetition {
+		t.Errorf("outcome %v after fivefold repetition, want a draw by repetition", outcome)
+	}
+
+	game = NewGame()
+	playMoves(t, game, shuffle...)
+	playMoves(t, game, shuffle...)
+	if !game.ClaimDraw() || game.Result() != Drawn || game.Outcome().Termination() != Repetition {
+		t.Errorf("claimed threefold repetition for outcome %v", game.Outcome())
+	}
+}
+
+func TestMoveRules(t *testing.T) {
+	game, err := NewGameFromFEN("4k3/r7/8/8/8/8/R7/4K3 w - - 99 60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if termination := game.ClaimableDraw(); termination != NotTerminated {
+		t.Errorf("claimable draw %v after 99 halfmoves", termination)
+	}
+	playMoves(t, game, "Ra3")
+	if termination := game.ClaimableDraw(); termination != FiftyMoveRule || game.Outcome().IsOver() {
+		t.Errorf("claimable draw %v and outcome %v after 100 halfmoves", termination, game.Outcome())
+	}
+
+	game, err = NewGameFromFEN("4k3/r7/8/8/8/8/R7/4K3 w - - 149 85")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Outcome().IsOver() {
+		t.Errorf("game over after 149 halfmoves: %v", game.Outcome())
+	}
+	playMoves(t, game, "Ra3")
+	if outcome := game.Outcome(); outcome.Result() != Drawn || outcome.Termination() != FiftyMoveRule {
+		t.Errorf("outcome %v after 150 halfmoves, want a draw by the seventy-five-move rule", outcome)
+	}
+
+	// Checkmate on the 150th halfmove still wins
+	game, err = NewGameFromFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 149 85")
+	if err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, game, "Ra8#")
+	if outcome := game.Outcome(); outcome.Result() != WhiteWon || outcome.Termination() != Checkmate {
+		t.Errorf("outcome %v after mate on the 150th halfmove, want white to win by checkmate", outcome)
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen          string
+		insufficient bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/1N2K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		// Bishops on dark squares c1 and f8
+		{"4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		// Bishops on c1 and c8, a dark and a light square
+		{"2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false},
+		{"1n2k3/8/8/8/8/8/8/1N2K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/1NN1K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", false},
+	}
+	for _, test := range tests {
+		game, err := NewGameFromFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drawn := game.Outcome().Termination() == InsufficientMaterial
+		if drawn != test.insufficient {
+			t.Errorf("%s: drawn by insufficient material is %v, want %v", test.fen, drawn, test.insufficient)
+		}
+	}
+}
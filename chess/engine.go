@@ -0,0 +1,459 @@
+package chess
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	mateScore     = 100000
+	infiniteScore = mateScore + 1
+	maxSearchPly  = 64
+	// Scores beyond this are mates found by the search, mateScore minus the plies to mate
+	mateThreshold = mateScore - maxSearchPly
+
+	defaultTranspositionTableMB = 64
+
+	// Score of a position the tablebases give as won, less the plies to it so that wins come sooner.  Below
+	// the mate scores, which are certain to the move.
+	tablebaseWinScore = mateThreshold - maxSearchPly - 1
+)
+
+// Limits on a search.  The search stops at whichever is reached first; with neither set it runs until stop
+// is called or maxSearchPly is reached.
+type searchLimits struct {
+	depth    int           // Maximum depth in plies, 0 for no limit
+	moveTime time.Duration // Time to think, 0 for no limit
+}
+
+// Progress of a search, reported after each completed iteration
+type searchInfo struct {
+	depth   int
+	score   int // Centipawns for the side to move, or a mate score
+	nodes   int
+	elapsed time.Duration
+	pv      []chessMove // Principal variation, the line the engine expects
+}
+
+// Mate in moves (negative when being mated) if score is a mate score, else 0
+func (si searchInfo) mateIn() int {
+	switch {
+	case si.score >= mateThreshold:
+		return (mateScore - si.score + 1) / 2
+	case si.score <= -mateThreshold:
+		return -(mateScore + si.score + 1) / 2
+	}
+	return 0
+}
+
+// An alpha-beta searcher with iterative deepening, quiescence search and a transposition table.  An engine
+// runs one search at a time, stopping any earlier one when it starts another, but stop may be called from any
+// goroutine.
+type engine struct {
+	board     chessBoard
+	evaluator *evaluator
+	table     transpositionTable
+	nodes     int
+	stopped   atomic.Bool
+	running   sync.WaitGroup // The search started by start, until it returns its move
+	deadline  time.Time
+	// Best move of the current iteration at the root
+	rootBestMove chessMove
+	// Probed for positions with few enough pieces, if set
+	tablebase *Tablebase
+	// The root moves searched, those that keep the best result when the root is in the tablebases, or nil for
+	// every move
+	rootMoves []chessMove
+
+	// Move ordering state.  Killers are quiet moves that caused a cutoff at the same ply, history scores
+	// quiet moves by how often they caused cutoffs anywhere.
+	killers [maxSearchPly][2]packedMove
+	history [3][64][64]int
+
+	// Reused per ply to avoid allocating at every node
+	moveLists  [maxSearchPly + 1][]chessMove
+	moveScores [maxSearchPly + 1][]int
+}
+
+func newEngine() *engine {
+	return &engine{evaluator: defaultEvaluator, table: newTranspositionTable(defaultTranspositionTableMB)}
+}
+
+// Forgets everything learned in earlier searches, for when a new game starts
+func (e *engine) reset() {
+	e.finishRunning()
+	e.table.clear()
+	e.history = [3][64][64]int{}
+}
+
+// Makes a running search return as soon as possible with the best move found so far
+func (e *engine) stop() {
+	e.stopped.Store(true)
+}
+
+// Searches board, which is not modified, and returns the best move found, or nilMove if there are no legal
+// moves.  report, if not nil, is called after each completed iteration.
+func (e *engine) search(board *chessBoard, limits searchLimits, report func(searchInfo)) chessMove {
+	e.finishRunning()
+	e.stopped.Store(false)
+	return e.run(board, limits, report)
+}
+
+// Starts searching board on a new goroutine and returns straight away.  done is called on that goroutine
+// with the best move.  A stop made as soon as start returns is not missed, as it could be if search were
+// called on a goroutine of the caller's own.  A search still running is stopped first, and start waits for
+// it to return its move, though its done may still be running.  The board's own history of positions is
+// enough for the search to find repetitions, so the game's record isn't needed.
+func (e *engine) start(board *chessBoard, _ *gameRecord, limits searchLimits, report func(searchInfo), done func(chessMove)) {
+	e.finishRunning()
+	e.stopped.Store(false)
+	boardCopy := *board
+	boardCopy.positionHistory = append([]uint64{}, board.positionHistory...)
+	e.running.Add(1)
+	go func() {
+		move := e.run(&boardCopy, limits, report)
+		e.running.Done()
+		done(move)
+	}()
+}
+
+// Stops the search started by start, if it is still running, and waits for it to return
+func (e *engine) finishRunning() {
+	e.stop()
+	e.running.Wait()
+}
+
+func (e *engine) run(board *chessBoard, limits searchLimits, report func(searchInfo)) chessMove {
+	start := time.Now()
+
+	e.board = *board
+	// The search appends to the history for repetition detection, so it needs a copy of its own
+	e.board.positionHistory = append([]uint64{}, board.positionHistory...)
+	e.nodes = 0
+	e.deadline = time.Time{}
+	if limits.moveTime > 0 {
+		e.deadline = start.Add(limits.moveTime)
+	}
+	e.killers = [maxSearchPly][2]packedMove{}
+	e.ageHistory()
+
+	rootMoves := e.board.getAllValidMovesForPlayer(e.board.sideToMove)
+	if len(rootMoves) == 0 {
+		return nilMove
+	}
+	e.rootMoves = nil
+	if e.tablebase != nil {
+		if moves, err := e.tablebase.rootMoves(&e.board); err == nil {
+			rootMoves, e.rootMoves = moves, moves
+		}
+	}
+	bestMove := rootMoves[0]
+
+	maxDepth := limits.depth
+	if maxDepth <= 0 || maxDepth > maxSearchPly {
+		maxDepth = maxSearchPly
+	}
+	for depth := 1; depth <= maxDepth; depth++ {
+		score := e.negamax(depth, 0, -infiniteScore, infiniteScore)
+		// An interrupted iteration's result can't be trusted, so keep the last complete one
+		if e.stopped.Load() {
+			break
+		}
+
+		bestMove = e.rootBestMove
+		pv := e.principalVariation(bestMove, depth)
+		info := searchInfo{depth: depth, score: score, nodes: e.nodes, elapsed: time.Since(start), pv: pv}
+		if report != nil {
+			report(info)
+		}
+
+		// Nothing to think about with a single legal move, or once a forced mate has been found
+		if len(rootMoves) == 1 || info.mateIn() != 0 {
+			break
+		}
+	}
+
+	return bestMove
+}
+
+// Reports whether the search has to stop, checking the clock every few thousand nodes
+func (e *engine) shouldStop() bool {
+	if e.nodes&2047 == 0 && !e.deadline.IsZero() && time.Now().After(e.deadline) {
+		e.stopped.Store(true)
+	}
+	return e.stopped.Load()
+}
+
+// Reports whether the position is drawn by the rules, counting a single repetition as a draw since the
+// side that could avoid it would have done so.  Checkmate on the move that reaches the fifty-move limit still
+// wins.
+func (e *engine) isDraw() bool {
+	cb := &e.board
+	if cb.hasInsufficientMaterial() {
+		return true
+	}
+	if cb.halfmoveClock >= fiftyMoveRulePlies && !cb.playerInCheckMate(cb.sideToMove) {
+		return true
+	}
+
+	// Only positions since the last capture or pawn move, with the same side to move, can repeat
+	history := cb.positionHistory
+	for i := len(history) - 3; i >= 0 && i >= len(history)-1-cb.halfmoveClock; i -= 2 {
+		if history[i] == cb.hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *engine) negamax(depth int, ply int, alpha int, beta int) int {
+	cb := &e.board
+
+	if ply > 0 && e.isDraw() {
+		return 0
+	}
+	if ply >= maxSearchPly {
+		return e.evaluator.evaluate(cb)
+	}
+
+	// The tables only count distances from captures and pawn moves, so they are only relied on right after one
+	if ply > 0 && e.tablebase != nil && cb.halfmoveClock == 0 && e.tablebase.checkCovered(cb) == nil {
+		if wdl, err := e.tablebase.probeWDL(cb); err == nil {
+			return tablebaseScore(wdl, ply)
+		}
+	}
+
+	inCheck := cb.playerInCheck(cb.sideToMove)
+	// Look one ply further after a check, as checks often lead to forced lines
+	if inCheck {
+		depth++
+	}
+	if depth <= 0 {
+		return e.quiescence(ply, alpha, beta)
+	}
+
+	e.nodes++
+	if e.shouldStop() {
+		return 0
+	}
+
+	ttMove := noPackedMove
+	if entry, found := e.table.probe(cb.hash); found {
+		ttMove = entry.move
+		if ply > 0 && int(entry.depth) >= depth {
+			score := scoreFromTT(int(entry.score), ply)
+			switch {
+			case entry.bound == exactBound,
+				entry.bound == lowerBound && score >= beta,
+				entry.bound == upperBound && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves := cb.generateLegalMoves(cb.sideToMove, e.moveLists[ply][:0])
+	e.moveLists[ply] = moves
+	if len(moves) == 0 {
+		if inCheck {
+			return -mateScore + ply
+		}
+		return 0
+	}
+	e.orderMoves(moves, ply, ttMove)
+
+	bestScore := -infiniteScore
+	bestMove := noPackedMove
+	bound := upperBound
+	for _, move := range moves {
+		if ply == 0 && e.rootMoves != nil && !slices.Contains(e.rootMoves, move) {
+			continue
+		}
+		undo := cb.movePiece(move)
+		score := -e.negamax(depth-1, ply+1, -beta, -alpha)
+		cb.unmakeMove(undo)
+		if e.stopped.Load() {
+			return 0
+		}
+
+		if score <= bestScore {
+			continue
+		}
+		bestScore = score
+		bestMove = packMove(move)
+		if ply == 0 {
+			e.rootBestMove = move
+		}
+		if score <= alpha {
+			continue
+		}
+		alpha = score
+		bound = exactBound
+		if alpha >= beta {
+			bound = lowerBound
+			if !move.is(captureFlag) && !move.is(promotionFlag) {
+				e.recordQuietCutoff(move, ply, depth)
+			}
+			break
+		}
+	}
+
+	e.table.store(cb.hash, depth, scoreToTT(bestScore, ply), bound, bestMove)
+	return bestScore
+}
+
+// The score of a position the tablebases give as wdl.  Cursed wins and blessed losses are draws by the
+// fifty-move rule.
+func tablebaseScore(wdl WDL, ply int) int {
+	switch wdl {
+	case TablebaseWin:
+		return tablebaseWinScore - ply
+	case TablebaseLoss:
+		return -tablebaseWinScore + ply
+	}
+	return 0
+}
+
+// Searches captures and promotions until the position is quiet, so that the evaluation isn't taken in the
+// middle of an exchange.  All moves are searched when in check.
+func (e *engine) quiescence(ply int, alpha int, beta int) int {
+	cb := &e.board
+
+	e.nodes++
+	if e.shouldStop() {
+		return 0
+	}
+	if ply >= maxSearchPly {
+		return e.evaluator.evaluate(cb)
+	}
+
+	inCheck := cb.playerInCheck(cb.sideToMove)
+	if !inCheck {
+		// Standing pat: the side to move can usually do at least as well as the static evaluation
+		standPat := e.evaluator.evaluate(cb)
+		if standPat >= beta {
+			return standPat
+		}
+		alpha = max(alpha, standPat)
+	}
+
+	moves := cb.generateLegalMoves(cb.sideToMove, e.moveLists[ply][:0])
+	e.moveLists[ply] = moves
+	if len(moves) == 0 {
+		if inCheck {
+			return -mateScore + ply
+		}
+		return 0
+	}
+	e.orderMoves(moves, ply, noPackedMove)
+
+	for _, move := range moves {
+		if !inCheck && !move.is(captureFlag) && !move.is(promotionFlag) {
+			continue
+		}
+
+		undo := cb.movePiece(move)
+		score := -e.quiescence(ply+1, -beta, -alpha)
+		cb.unmakeMove(undo)
+		if e.stopped.Load() {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+
+	return alpha
+}
+
+// Sorts moves so the likeliest best come first: the transposition table move, then captures of valuable
+// pieces by cheap ones (MVV-LVA), promotions, killers and finally quiet moves by history score
+func (e *engine) orderMoves(moves []chessMove, ply int, ttMove packedMove) {
+	scores := e.moveScores[ply][:0]
+	for _, move := range moves {
+		packed := packMove(move)
+		score := e.history[move.movedPiece.color][squareIndex(move.from)][squareIndex(move.to)]
+		switch {
+		case packed == ttMove:
+			score = 1 << 30
+		case move.is(captureFlag) || move.is(promotionFlag):
+			score = 1<<24 + 16*pieceValues[move.capturedPiece.pieceType] - pieceValues[move.movedPiece.pieceType] + pieceValues[move.promotion]
+		case packed == e.killers[ply][0]:
+			score = 1 << 22
+		case packed == e.killers[ply][1]:
+			score = 1<<22 - 1
+		}
+		scores = append(scores, score)
+	}
+	e.moveScores[ply] = scores
+
+	// Insertion sort, as move lists are short
+	for i := 1; i < len(moves); i++ {
+		move, score := moves[i], scores[i]
+		j := i
+		for ; j > 0 && scores[j-1] < score; j-- {
+			moves[j], scores[j] = moves[j-1], scores[j-1]
+		}
+		moves[j], scores[j] = move, score
+	}
+}
+
+func (e *engine) recordQuietCutoff(move chessMove, ply int, depth int) {
+	packed := packMove(move)
+	if e.killers[ply][0] != packed {
+		e.killers[ply][1] = e.killers[ply][0]
+		e.killers[ply][0] = packed
+	}
+
+	history := &e.history[move.movedPiece.color][squareIndex(move.from)][squareIndex(move.to)]
+	*history += depth * depth
+	// Keep history scores below the killer and capture scores
+	if *history >= 1<<20 {
+		e.ageHistory()
+	}
+}
+
+// Halves the history scores so that recent cutoffs weigh more
+func (e *engine) ageHistory() {
+	for color := range e.history {
+		for from := range e.history[color] {
+			for to := range e.history[color][from] {
+				e.history[color][from][to] /= 2
+			}
+		}
+	}
+}
+
+// Follows best moves through the transposition table after the best root move, up to depth moves
+func (e *engine) principalVariation(bestMove chessMove, depth int) []chessMove {
+	board := e.board.deepCopy()
+
+	pv := []chessMove{bestMove}
+	board.movePiece(bestMove)
+	for len(pv) < depth {
+		entry, found := e.table.probe(board.hash)
+		if !found || entry.move == noPackedMove {
+			break
+		}
+		move, ok := board.unpackMove(entry.move)
+		if !ok {
+			break
+		}
+		pv = append(pv, move)
+		board.movePiece(move)
+	}
+	return pv
+}
+
+// Returns the legal move matching a packed move, if there is one
+func (cb *chessBoard) unpackMove(packed packedMove) (chessMove, bool) {
+	for _, move := range cb.getAllValidMovesForPlayer(cb.sideToMove) {
+		if packMove(move) == packed {
+			return move, true
+		}
+	}
+	return nilMove, false
+}
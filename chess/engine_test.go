@@ -0,0 +1,83 @@
+package chess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineFindsMate(t *testing.T) {
+	tests := []struct {
+		fen    string
+		move   string
+		mateIn int
+	}{
+		// Back rank mate
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", 1},
+		// Mate on the hundredth ply without a capture or pawn move wins rather than draws by the fifty-move rule
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 99 80", "a1a8", 1},
+		// Ra6, and bxa6 allows b7 mate while anything else allows Rxa7
+		{"kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", "a1a6", 2},
+	}
+	for _, test := range tests {
+		var board chessBoard
+		if err := board.loadFEN(test.fen); err != nil {
+			t.Fatal(err)
+		}
+		infos, move := searchWith(t, newEngine(), &board, nil, searchLimits{depth: 6}, 0)
+		if got := board.moveOf(move).String(); got != test.move {
+			t.Errorf("%s: best move %s, want %s", test.fen, got, test.move)
+		}
+		if len(infos) == 0 {
+			t.Fatalf("%s: no info reports", test.fen)
+		}
+		if last := infos[len(infos)-1]; last.mateIn() != test.mateIn {
+			t.Errorf("%s: mate in %d, want mate in %d", test.fen, last.mateIn(), test.mateIn)
+		}
+	}
+}
+
+func TestEngineStop(t *testing.T) {
+	var board chessBoard
+	board.init()
+
+	// Without limits the search runs until stopped
+	started := time.Now()
+	if _, move := searchWith(t, newEngine(), &board, nil, searchLimits{}, 50*time.Millisecond); !board.isValidMove(move) {
+		t.Errorf("best move %s is not legal from the initial position", move)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("stopped search took %v to return", elapsed)
+	}
+
+	// A move time stops the search without anyone calling stop
+	started = time.Now()
+	if _, move := searchWith(t, newEngine(), &board, nil, searchLimits{moveTime: 100 * time.Millisecond}, 0); !board.isValidMove(move) {
+		t.Errorf("best move %s is not legal from the initial position", move)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("search with a 100ms move time took %v", elapsed)
+	}
+}
+
+// Starting a search while another runs stops the first, which still gives its move, before the second starts
+func TestEngineRestart(t *testing.T) {
+	var board chessBoard
+	board.init()
+	engine := newEngine()
+
+	first, second := make(chan chessMove, 1), make(chan chessMove, 1)
+	engine.start(&board, nil, searchLimits{}, nil, func(move chessMove) { first <- move })
+	time.Sleep(20 * time.Millisecond)
+	engine.start(&board, nil, searchLimits{depth: 3}, nil, func(move chessMove) { second <- move })
+
+	for name, moves := range map[string]chan chessMove{"first": first, "second": second} {
+		select {
+		case move := <-moves:
+			if !board.isValidMove(move) {
+				t.Errorf("%s search gave %s, which is not legal from the initial position", name, move)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s search gave no move", name)
+		}
+	}
+}
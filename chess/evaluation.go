@@ -0,0 +1,349 @@
+package chess
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Centipawn value of each piece type, indexed by piece.  Used to order captures in the search; the
+// evaluation takes its material values from the weights.
+var pieceValues = [7]int{
+	empty:  0,
+	pawn:   100,
+	bishop: 330,
+	knight: 320,
+	rook:   500,
+	queen:  900,
+	king:   0,
+}
+
+var pieceNames = map[string]piece{
+	"pawn":   pawn,
+	"knight": knight,
+	"bishop": bishop,
+	"rook":   rook,
+	"queen":  queen,
+	"king":   king,
+}
+
+// A weight with separate midgame and endgame values.  The evaluation blends them by game phase.
+type taperedScore [2]int
+
+// Requires both values, as encoding/json would otherwise zero the endgame value of a weight given only one
+func (ts *taperedScore) UnmarshalJSON(data []byte) error {
+	return unmarshalFixedArray(data, ts[:], "a midgame and an endgame value")
+}
+
+func (ts taperedScore) add(other taperedScore) taperedScore {
+	return taperedScore{ts[0] + other[0], ts[1] + other[1]}
+}
+
+func (ts taperedScore) times(factor int) taperedScore {
+	return taperedScore{ts[0] * factor, ts[1] * factor}
+}
+
+// A piece-square table as stored in a weights file.  Either half may be left out.
+type pieceSquareTable struct {
+	Midgame *squareValues `json:"midgame"`
+	Endgame *squareValues `json:"endgame"`
+}
+
+// One half of a piece-square table, a value for every square
+type squareValues [64]int
+
+func (sv *squareValues) UnmarshalJSON(data []byte) error {
+	return unmarshalFixedArray(data, sv[:], "a value for each of the 64 squares")
+}
+
+// Passed pawn weights, one for each rank
+type passedPawnScores [8]taperedScore
+
+func (ps *passedPawnScores) UnmarshalJSON(data []byte) error {
+	return unmarshalFixedArray(data, ps[:], "a weight for each of the 8 ranks")
+}
+
+// Decodes a JSON array that must fill dest exactly.  encoding/json would zero the entries a short array leaves
+// out, which a weights file meant to keep at their defaults, and silently drop those of a long one.
+func unmarshalFixedArray[T any](data []byte, dest []T, want string) error {
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		return nil // null leaves the weights as they are
+	}
+	if len(values) != len(dest) {
+		return fmt.Errorf("expected %s, got %d values", want, len(values))
+	}
+	copy(dest, values)
+	return nil
+}
+
+// The tunable terms of the evaluation, as stored in a weights file.  Pieces are named "pawn", "knight",
+// "bishop", "rook", "queen" and "king".  Piece-square tables are laid out as a board seen from white's
+// side, rank 8 first, and are mirrored for black.  passedPawn is indexed by the pawn's rank counted from
+// its own side, 0 to 7.
+type evaluationWeights struct {
+	Material          map[string]taperedScore     `json:"material"`
+	PieceSquareTables map[string]pieceSquareTable `json:"pieceSquareTables"`
+	Mobility          map[string]taperedScore     `json:"mobility"` // Per square a piece can move to
+	DoubledPawn       taperedScore                `json:"doubledPawn"`
+	IsolatedPawn      taperedScore                `json:"isolatedPawn"`
+	PassedPawn        passedPawnScores            `json:"passedPawn"`
+	BishopPair        taperedScore                `json:"bishopPair"`
+	KingShield        taperedScore                `json:"kingShield"`     // Per pawn in front of the king
+	KingZoneAttack    taperedScore                `json:"kingZoneAttack"` // Per attack on a square next to the opponent's king
+}
+
+//go:embed assets/evaluation.json
+var defaultEvaluationWeights []byte
+
+// Game phase contributed by each piece type.  The phase runs from maxGamePhase with all pieces on the board
+// down to 0 with only kings and pawns.
+var gamePhaseWeights = [7]int{knight: 1, bishop: 1, rook: 2, queen: 4}
+
+const maxGamePhase = 24
+
+// Evaluation weights prepared for fast lookup
+type evaluator struct {
+	// Material and piece-square value of each piece on each square, by color
+	pieceSquare    [3][7][64]taperedScore
+	mobility       [7]taperedScore
+	doubledPawn    taperedScore
+	isolatedPawn   taperedScore
+	passedPawn     [8]taperedScore
+	bishopPair     taperedScore
+	kingShield     taperedScore
+	kingZoneAttack taperedScore
+}
+
+var defaultEvaluator = mustLoadDefaultEvaluator()
+
+func mustLoadDefaultEvaluator() *evaluator {
+	ev, err := parseEvaluator(defaultEvaluationWeights)
+	if err != nil {
+		panic(fmt.Sprintf("default evaluation weights: %v", err))
+	}
+	return ev
+}
+
+// Reads evaluation weights from a JSON file.  Terms missing from the file keep their default values, as does
+// the midgame or endgame half of a piece-square table the file gives only the other half of.  The terms given
+// are given in full: a weight with one value or a table missing squares is an error.
+func loadEvaluator(path string) (*evaluator, error) {
+	weightsJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := parseEvaluator(defaultEvaluationWeights, weightsJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ev, nil
+}
+
+// Builds an evaluator from weights files, each overriding the terms set by the ones before it
+func parseEvaluator(weightsFiles ...[]byte) (*evaluator, error) {
+	var weights evaluationWeights
+	tables := map[string]pieceSquareTable{}
+	for _, weightsJSON := range weightsFiles {
+		// Decoding replaces whole map entries, so merge the halves of the tables separately
+		weights.PieceSquareTables = nil
+		if err := json.Unmarshal(weightsJSON, &weights); err != nil {
+			return nil, fmt.Errorf("invalid evaluation weights: %w", err)
+		}
+		for name, table := range weights.PieceSquareTables {
+			merged := tables[name]
+			if table.Midgame != nil {
+				merged.Midgame = table.Midgame
+			}
+			if table.Endgame != nil {
+				merged.Endgame = table.Endgame
+			}
+			tables[name] = merged
+		}
+	}
+
+	ev := &evaluator{
+		doubledPawn:    weights.DoubledPawn,
+		isolatedPawn:   weights.IsolatedPawn,
+		passedPawn:     [8]taperedScore(weights.PassedPawn),
+		bishopPair:     weights.BishopPair,
+		kingShield:     weights.KingShield,
+		kingZoneAttack: weights.KingZoneAttack,
+	}
+
+	for _, table := range []map[string]taperedScore{weights.Material, weights.Mobility} {
+		for name := range table {
+			if _, ok := pieceNames[name]; !ok {
+				return nil, fmt.Errorf("invalid evaluation weights: unknown piece %q", name)
+			}
+		}
+	}
+	for name := range tables {
+		if _, ok := pieceNames[name]; !ok {
+			return nil, fmt.Errorf("invalid evaluation weights: unknown piece %q", name)
+		}
+	}
+
+	for name, pieceType := range pieceNames {
+		ev.mobility[pieceType] = weights.Mobility[name]
+		var midgame, endgame squareValues
+		table := tables[name]
+		if table.Midgame != nil {
+			midgame = *table.Midgame
+		}
+		if table.Endgame != nil {
+			endgame = *table.Endgame
+		}
+		for i := range 64 {
+			// Table entries run from a8 to h1
+			whiteSquare := vector2{i % 8, 7 - i/8}
+			blackSquare := vector2{i % 8, i / 8}
+			value := weights.Material[name].add(taperedScore{midgame[i], endgame[i]})
+			ev.pieceSquare[white][pieceType][squareIndex(whiteSquare)] = value
+			ev.pieceSquare[black][pieceType][squareIndex(blackSquare)] = value
+		}
+	}
+
+	return ev, nil
+}
+
+// Scores the position in centipawns from the point of view of the side to move, using the default weights
+func (cb *chessBoard) evaluate() int {
+	return defaultEvaluator.evaluate(cb)
+}
+
+// Scores the position in centipawns from the point of view of the side to move
+func (ev *evaluator) evaluate(cb *chessBoard) int {
+	score := ev.evaluateSide(cb, white).add(ev.evaluateSide(cb, black).times(-1))
+
+	phase := 0
+	for _, color := range []pieceColor{white, black} {
+		for pieceType, weight := range gamePhaseWeights {
+			phase += weight * cb.pieceBitboards[color][pieceType].count()
+		}
+	}
+	phase = min(phase, maxGamePhase)
+
+	blended := (score[0]*phase + score[1]*(maxGamePhase-phase)) / maxGamePhase
+	if cb.sideToMove == black {
+		return -blended
+	}
+	return blended
+}
+
+// The terms that favor color
+func (ev *evaluator) evaluateSide(cb *chessBoard, color pieceColor) taperedScore {
+	var score taperedScore
+	opponentColor := color.oppositeColor()
+	own := cb.colorBitboards[color]
+	occupied := cb.occupied()
+	pieces := &cb.pieceBitboards[color]
+
+	// Material and piece-square tables
+	for pieceType := pawn; pieceType <= king; pieceType++ {
+		for squares := pieces[pieceType]; squares != 0; {
+			score = score.add(ev.pieceSquare[color][pieceType][squares.popFirst()])
+		}
+	}
+
+	if pieces[bishop].count() >= 2 {
+		score = score.add(ev.bishopPair)
+	}
+
+	// Mobility, and attacks on the squares around the opponent's king
+	opponentKingZone := kingAttacks[cb.kingIndex(opponentColor)]
+	kingZoneAttacks := 0
+	for pieceType := knight; pieceType <= queen; pieceType++ {
+		for squares := pieces[pieceType]; squares != 0; {
+			index := squares.popFirst()
+			var attacks bitboard
+			switch pieceType {
+			case knight:
+				attacks = knightAttacks[index]
+			case bishop:
+				attacks = bishopAttacks(index, occupied)
+			case rook:
+				attacks = rookAttacks(index, occupied)
+			case queen:
+				attacks = bishopAttacks(index, occupied) | rookAttacks(index, occupied)
+			}
+			score = score.add(ev.mobility[pieceType].times((attacks &^ own).count()))
+			kingZoneAttacks += (attacks & opponentKingZone).count()
+		}
+	}
+	score = score.add(ev.kingZoneAttack.times(kingZoneAttacks))
+
+	score = score.add(ev.pawnStructure(cb, color))
+
+	// Pawns shielding the king from the front, one or two ranks ahead on its own and neighboring files
+	kingSquare := cb.getKingSquare(color)
+	forward := 1
+	if color == black {
+		forward = -1
+	}
+	shieldPawns := 0
+	for file := kingSquare.x - 1; file <= kingSquare.x+1; file++ {
+		for distance := 1; distance <= 2; distance++ {
+			square := vector2{file, kingSquare.y + forward*distance}
+			if onBoard(square) && cb.getPiece(square) == (chessPiece{pawn, color}) {
+				shieldPawns++
+				break
+			}
+		}
+	}
+	score = score.add(ev.kingShield.times(shieldPawns))
+
+	return score
+}
+
+// Doubled, isolated and passed pawns of color
+func (ev *evaluator) pawnStructure(cb *chessBoard, color pieceColor) taperedScore {
+	var score taperedScore
+
+	var pawnFiles, opponentPawnFiles [8][]int // Ranks of the pawns on each file
+	for squares := cb.pieceBitboards[color][pawn]; squares != 0; {
+		square := indexToSquare(squares.popFirst())
+		pawnFiles[square.x] = append(pawnFiles[square.x], square.y)
+	}
+	for squares := cb.pieceBitboards[color.oppositeColor()][pawn]; squares != 0; {
+		square := indexToSquare(squares.popFirst())
+		opponentPawnFiles[square.x] = append(opponentPawnFiles[square.x], square.y)
+	}
+
+	for file, ranks := range pawnFiles {
+		if len(ranks) > 1 {
+			score = score.add(ev.doubledPawn.times(len(ranks) - 1))
+		}
+
+		hasNeighbor := (file > 0 && len(pawnFiles[file-1]) > 0) || (file < 7 && len(pawnFiles[file+1]) > 0)
+		if !hasNeighbor {
+			score = score.add(ev.isolatedPawn.times(len(ranks)))
+		}
+
+		// A pawn is passed when no opposing pawn ahead of it can block or capture it
+		for _, rank := range ranks {
+			passed := true
+			for opponentFile := max(file-1, 0); opponentFile <= min(file+1, 7) && passed; opponentFile++ {
+				for _, opponentRank := range opponentPawnFiles[opponentFile] {
+					if (color == white && opponentRank > rank) || (color == black && opponentRank < rank) {
+						passed = false
+						break
+					}
+				}
+			}
+			if passed {
+				relativeRank := rank
+				if color == black {
+					relativeRank = 7 - rank
+				}
+				score = score.add(ev.passedPawn[relativeRank])
+			}
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,99 @@
+package chess
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func evaluateFEN(t *testing.T, ev *evaluator, fen string) int {
+	t.Helper()
+	var board chessBoard
+	if err := board.loadFEN(fen); err != nil {
+		t.Fatal(err)
+	}
+	return ev.evaluate(&board)
+}
+
+func TestEvaluate(t *testing.T) {
+	if score := evaluateFEN(t, defaultEvaluator, StartingFEN); score != 0 {
+		t.Errorf("initial position scores %d, want 0", score)
+	}
+
+	// The score is for the side to move, and a position mirrored for the other side scores the same
+	whiteToMove := evaluateFEN(t, defaultEvaluator, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	blackToMove := evaluateFEN(t, defaultEvaluator, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 2 3")
+	mirrored := evaluateFEN(t, defaultEvaluator, "rnbqkb1r/pppp1ppp/5n2/4p3/4P3/2N5/PPPP1PPP/R1BQKBNR b KQkq - 2 3")
+	if blackToMove != -whiteToMove || mirrored != whiteToMove {
+		t.Errorf("scores %d with white to move, %d with black to move and %d mirrored", whiteToMove, blackToMove, mirrored)
+	}
+
+	if score := evaluateFEN(t, defaultEvaluator, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); score < 800 {
+		t.Errorf("a queen up scores %d", score)
+	}
+}
+
+func TestEvaluateKingZoneAttacks(t *testing.T) {
+	// The knight on a6 attacks c7 next to the king on d8 but no square next to the king on e8, and the king
+	// scores the same from either square
+	attacking := evaluateFEN(t, defaultEvaluator, "3k4/8/N7/8/8/8/8/4K3 w - - 0 1")
+	notAttacking := evaluateFEN(t, defaultEvaluator, "4k3/8/N7/8/8/8/8/4K3 w - - 0 1")
+	if attacking <= notAttacking {
+		t.Errorf("attacking the king's zone scores %d, not attacking it %d", attacking, notAttacking)
+	}
+}
+
+func TestLoadEvaluator(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, weightsJSON string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(weightsJSON), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Only the terms in the file change, down to half of a piece-square table
+	path := write("weights.json", `{
+		"material": {"queen": [1000, 1100]},
+		"kingShield": [20, 5],
+		"pieceSquareTables": {"knight": {"midgame": [`+strings.Repeat("7, ", 63)+`7]}}
+	}`)
+	ev, err := loadEvaluator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1 := squareIndex(vector2{0, 0})
+	if got, want := ev.pieceSquare[white][queen][a1], defaultEvaluator.pieceSquare[white][queen][a1].add(taperedScore{50, 150}); got != want {
+		t.Errorf("queen on a1 weighs %v, want %v", got, want)
+	}
+	if got, want := ev.pieceSquare[white][rook][a1], defaultEvaluator.pieceSquare[white][rook][a1]; got != want {
+		t.Errorf("rook on a1 weighs %v, want the default %v", got, want)
+	}
+	// The knight's endgame table is kept
+	if got, want := ev.pieceSquare[white][knight][a1], (taperedScore{320 + 7, 300 - 50}); got != want {
+		t.Errorf("knight on a1 weighs %v, want %v", got, want)
+	}
+	if ev.kingShield != (taperedScore{20, 5}) || ev.bishopPair != defaultEvaluator.bishopPair {
+		t.Errorf("king shield %v and bishop pair %v", ev.kingShield, ev.bishopPair)
+	}
+
+	for name, weightsJSON := range map[string]string{
+		"invalid.json":       `{"material": `,
+		"unknown-piece.json": `{"mobility": {"archbishop": [1, 1]}}`,
+		"unknown-table.json": `{"pieceSquareTables": {"archbishop": {"midgame": []}}}`,
+		// Arrays shorter or longer than the term would leave entries zeroed or ignored
+		"partial-passed-pawn.json": `{"passedPawn": [[0, 0], [5, 10], [10, 20]]}`,
+		"long-passed-pawn.json":    `{"passedPawn": [` + strings.Repeat("[1, 1], ", 8) + `[1, 1]]}`,
+		"midgame-only.json":        `{"doubledPawn": [-10]}`,
+		"short-table.json":         `{"pieceSquareTables": {"knight": {"endgame": [1, 2, 3]}}}`,
+	} {
+		if _, err := loadEvaluator(write(name, weightsJSON)); err == nil {
+			t.Errorf("loaded %s", name)
+		}
+	}
+	if _, err := loadEvaluator(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
@@ -0,0 +1,353 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var fenPieceChars = map[byte]chessPiece{
+	'P': {pawn, white}, 'N': {knight, white}, 'B': {bishop, white},
+	'R': {rook, white}, 'Q': {queen, white}, 'K': {king, white},
+	'p': {pawn, black}, 'n': {knight, black}, 'b': {bishop, black},
+	'r': {rook, black}, 'q': {queen, black}, 'k': {king, black},
+}
+
+func (cp chessPiece) fenChar() byte {
+	for char, piece := range fenPieceChars {
+		if piece == cp {
+			return char
+		}
+	}
+	panic(fmt.Sprintf("%v has no FEN representation", cp))
+}
+
+func fenError(format string, args ...any) error {
+	return fmt.Errorf("invalid FEN: "+format, args...)
+}
+
+// Replaces the board state with the position described by fen.  The board is left untouched if fen is invalid.
+func (cb *chessBoard) loadFEN(fen string) error {
+	fields := strings.Fields(fen)
+	// The move counters are commonly omitted, so default them like most tools do
+	if len(fields) == 4 {
+		fields = append(fields, "0", "1")
+	}
+	if len(fields) != 6 {
+		return fenError("expected 6 space separated fields, got %d", len(fields))
+	}
+
+	var newBoard chessBoard
+
+	if err := newBoard.parsePlacement(fields[0]); err != nil {
+		return err
+	}
+
+	switch fields[1] {
+	case "w":
+		newBoard.sideToMove = white
+	case "b":
+		newBoard.sideToMove = black
+	default:
+		return fenError("side to move must be \"w\" or \"b\", got %q", fields[1])
+	}
+
+	if err := newBoard.parseCastlingRights(fields[2]); err != nil {
+		return err
+	}
+
+	if err := newBoard.parseEnPassantSquare(fields[3]); err != nil {
+		return err
+	}
+
+	halfmoveClock, err := strconv.Atoi(fields[4])
+	if err != nil || halfmoveClock < 0 {
+		return fenError("halfmove clock must be a non-negative integer, got %q", fields[4])
+	}
+	newBoard.halfmoveClock = halfmoveClock
+
+	fullmoveNumber, err := strconv.Atoi(fields[5])
+	if err != nil || fullmoveNumber < 1 {
+		return fenError("fullmove number must be a positive integer, got %q", fields[5])
+	}
+	newBoard.fullmoveNumber = fullmoveNumber
+
+	if newBoard.playerInCheck(newBoard.sideToMove.oppositeColor()) {
+		return fenError("%s is in check but it is not their turn", newBoard.sideToMove.oppositeColor().name())
+	}
+
+	newBoard.hash = newBoard.computeHash()
+	newBoard.positionHistory = []uint64{newBoard.hash}
+
+	*cb = newBoard
+	return nil
+}
+
+func (cb *chessBoard) parsePlacement(placement string) error {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return fenError("piece placement must describe 8 ranks, got %d", len(ranks))
+	}
+
+	kingCount := map[pieceColor]int{}
+
+	// FEN lists ranks from the 8th down to the 1st
+	for i, rankText := range ranks {
+		rank := 7 - i
+		file := 0
+		lastWasDigit := false
+		for j := 0; j < len(rankText); j++ {
+			char := rankText[j]
+			if char >= '1' && char <= '8' {
+				if lastWasDigit {
+					return fenError("rank %d has consecutive empty square counts", rank+1)
+				}
+				lastWasDigit = true
+				for range int(char - '0') {
+					if file < 8 {
+						cb.setPiece(vector2{file, rank}, emptyPiece)
+					}
+					file++
+				}
+				continue
+			}
+			lastWasDigit = false
+
+			piece, ok := fenPieceChars[char]
+			if !ok {
+				return fenError("unknown piece %q on rank %d", char, rank+1)
+			}
+			if piece.pieceType == pawn && (rank == 0 || rank == 7) {
+				return fenError("%s pawn on back rank %d", piece.color.name(), rank+1)
+			}
+			if piece.pieceType == king {
+				kingCount[piece.color]++
+			}
+			if file < 8 {
+				cb.setPiece(vector2{file, rank}, piece)
+			}
+			file++
+		}
+		if file != 8 {
+			return fenError("rank %d describes %d files instead of 8", rank+1, file)
+		}
+	}
+
+	for _, color := range []pieceColor{white, black} {
+		if kingCount[color] != 1 {
+			return fenError("expected exactly one %s king, found %d", color.name(), kingCount[color])
+		}
+	}
+
+	return nil
+}
+
+// Parses the castling rights field, in standard FEN, X-FEN or Shredder-FEN.  K and Q give the right to castle
+// with the outermost rook on that side of the king, and a file letter, as Chess960 needs when that isn't the
+// rook meant, with the rook on that file.  Uppercase letters are white's rights, lowercase black's.
+func (cb *chessBoard) parseCastlingRights(castling string) error {
+	if castling == "-" {
+		return nil
+	}
+
+	onlyFiles := true
+	for i := 0; i < len(castling); i++ {
+		char := castling[i]
+		color, lower := white, char
+		if char >= 'a' && char <= 'z' {
+			color = black
+		} else {
+			lower = char - 'A' + 'a'
+		}
+		rank := homeRank(color)
+		ownRook := chessPiece{rook, color}
+
+		kingSquare := cb.getKingSquare(color)
+		if kingSquare.y != rank {
+			return fenError("castling right %q requires the %s king on rank %d", char, color.name(), rank+1)
+		}
+
+		rookFile := -1
+		switch {
+		case lower == 'k':
+			onlyFiles = false
+			for file := 7; file > kingSquare.x && rookFile == -1; file-- {
+				if cb.getPiece(vector2{file, rank}) == ownRook {
+					rookFile = file
+				}
+			}
+		case lower == 'q':
+			onlyFiles = false
+			for file := 0; file < kingSquare.x && rookFile == -1; file++ {
+				if cb.getPiece(vector2{file, rank}) == ownRook {
+					rookFile = file
+				}
+			}
+		case lower >= 'a' && lower <= 'h':
+			if file := int(lower - 'a'); cb.getPiece(vector2{file, rank}) == ownRook && file != kingSquare.x {
+				rookFile = file
+			}
+		default:
+			return fenError("unknown castling right %q", char)
+		}
+		if rookFile == -1 {
+			return fenError("castling right %q has no %s rook to castle with", char, color.name())
+		}
+
+		rookSquare := vector2{rookFile, rank}
+		rookBit := squareBitboard(squareIndex(rookSquare))
+		if cb.castlingRooks&rookBit != 0 {
+			return fenError("castling right %q listed twice", char)
+		}
+		for others := cb.castlingRooks & rankSquares(rank); others != 0; {
+			if other := indexToSquare(others.popFirst()); (other.x > kingSquare.x) == (rookFile > kingSquare.x) {
+				return fenError("%s has two castling rights on the same side of the king", color.name())
+			}
+		}
+		cb.castlingRooks |= rookBit
+
+		// Castling with any other king or rook squares only happens in Chess960
+		if kingSquare.x != 4 || (rookFile != 0 && rookFile != 7) {
+			cb.chess960 = true
+		}
+	}
+
+	// Shredder-FEN names every rook by its file
+	if onlyFiles {
+		cb.chess960 = true
+		cb.shredderCastling = true
+	}
+	return nil
+}
+
+func (cb *chessBoard) parseEnPassantSquare(enPassant string) error {
+	cb.enpassantSquare = nilSquare
+	if enPassant == "-" {
+		return nil
+	}
+
+	square, ok := algebraicToSquare(enPassant)
+	if !ok {
+		return fenError("en passant target %q is not a square", enPassant)
+	}
+
+	// The target is the square skipped by a pawn of the side that just moved
+	movedColor := cb.sideToMove.oppositeColor()
+	direction, targetRank := 1, 2
+	if movedColor == black {
+		direction, targetRank = -1, 5
+	}
+	if square.y != targetRank {
+		return fenError("en passant target %s is not on rank %d", enPassant, targetRank+1)
+	}
+	if cb.getPiece(square.add(vector2{0, direction})) != (chessPiece{pawn, movedColor}) ||
+		!cb.isEmpty(square) || !cb.isEmpty(square.add(vector2{0, -direction})) {
+		return fenError("en passant target %s does not follow a %s double pawn push", enPassant, movedColor.name())
+	}
+
+	cb.enpassantSquare = square
+	return nil
+}
+
+// Serializes the board state to Forsyth-Edwards Notation
+func (cb *chessBoard) toFEN() string {
+	var fen strings.Builder
+
+	fen.WriteString(cb.placementFEN())
+
+	if cb.sideToMove == black {
+		fen.WriteString(" b ")
+	} else {
+		fen.WriteString(" w ")
+	}
+
+	fen.WriteString(cb.castlingRightsFEN())
+
+	if cb.enpassantSquare == nilSquare {
+		fen.WriteString(" -")
+	} else {
+		fen.WriteString(" " + squareToAlgebraic(cb.enpassantSquare))
+	}
+
+	fmt.Fprintf(&fen, " %d %d", cb.halfmoveClock, cb.fullmoveNumber)
+
+	return fen.String()
+}
+
+// Writes the piece placement field, rank by rank from the 8th
+func (cb *chessBoard) placementFEN() string {
+	var fen strings.Builder
+
+	for rank := 7; rank >= 0; rank-- {
+		emptyCount := 0
+		for file := range 8 {
+			piece := cb.getPiece(vector2{file, rank})
+			if piece == emptyPiece {
+				emptyCount++
+				continue
+			}
+			if emptyCount > 0 {
+				fen.WriteString(strconv.Itoa(emptyCount))
+				emptyCount = 0
+			}
+			fen.WriteByte(piece.fenChar())
+		}
+		if emptyCount > 0 {
+			fen.WriteString(strconv.Itoa(emptyCount))
+		}
+		if rank > 0 {
+			fen.WriteByte('/')
+		}
+	}
+
+	return fen.String()
+}
+
+// Writes the castling rights field.  Rights are written as K and Q where those name the rook that castles, as
+// X-FEN does, and otherwise by the rook's file, as Chess960 needs.  Shredder-FEN always uses the file.
+func (cb *chessBoard) castlingRightsFEN() string {
+	castling := ""
+	for _, color := range []pieceColor{white, black} {
+		rooks := cb.castlingRooks & cb.pieceBitboards[color][rook]
+		if rooks == 0 {
+			continue
+		}
+		rank := homeRank(color)
+		kingFile := cb.getKingSquare(color).x
+
+		// Whether no rook of color stands between file and the edge of the board in the direction of step
+		outermost := func(file int, step int) bool {
+			for other := file + step; other >= 0 && other < 8; other += step {
+				if cb.getPiece(vector2{other, rank}) == (chessPiece{rook, color}) {
+					return false
+				}
+			}
+			return true
+		}
+
+		// Kingside first
+		for file := 7; file >= 0; file-- {
+			if !rooks.has(squareIndex(vector2{file, rank})) {
+				continue
+			}
+			letter := byte('A' + file)
+			switch {
+			case cb.shredderCastling:
+			case file > kingFile && outermost(file, 1):
+				letter = 'K'
+			case file < kingFile && outermost(file, -1):
+				letter = 'Q'
+			}
+			if color == black {
+				letter += 'a' - 'A'
+			}
+			castling += string(letter)
+		}
+	}
+	if castling == "" {
+		return "-"
+	}
+	return castling
+}
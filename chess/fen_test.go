@@ -0,0 +1,69 @@
+package chess
+
+import "testing"
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 b - - 99 150",
+	}
+	for _, fen := range fens {
+		game, err := NewGameFromFEN(fen)
+		if err != nil {
+			t.Errorf("%q: %v", fen, err)
+			continue
+		}
+		if game.FEN() != fen {
+			t.Errorf("%q written back as %q", fen, game.FEN())
+		}
+	}
+
+	// The move counters may be left out
+	game, err := NewGameFromFEN("4k3/8/8/8/8/8/8/4K3 w - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "4k3/8/8/8/8/8/8/4K3 w - - 0 1"; game.FEN() != want {
+		t.Errorf("counters defaulted to %q, want %q", game.FEN(), want)
+	}
+}
+
+func TestFENRejected(t *testing.T) {
+	tests := map[string]string{
+		"too few fields":               "4k3/8/8/8/8/8/8/4K3 w -",
+		"seven ranks":                  "4k3/8/8/8/8/8/4K3 w - - 0 1",
+		"a short rank":                 "4k3/8/8/8/8/8/8/4K2 w - - 0 1",
+		"a long rank":                  "4k3/8/8/8/8/8/8/4K4 w - - 0 1",
+		"consecutive empty counts":     "4k3/8/8/8/8/8/8/4K12 w - - 0 1",
+		"an unknown piece":             "4k3/8/8/8/8/8/8/4K2X w - - 0 1",
+		"two white kings":              "4k3/8/8/8/8/8/8/3KK3 w - - 0 1",
+		"no black king":                "8/8/8/8/8/8/8/4K3 w - - 0 1",
+		"a pawn on the back rank":      "4k2P/8/8/8/8/8/8/4K3 w - - 0 1",
+		"a bad side to move":           "4k3/8/8/8/8/8/8/4K3 x - - 0 1",
+		"an unknown castling right":    "4k3/8/8/8/8/8/8/4K3 w X - 0 1",
+		"en passant off a square":      "4k3/8/8/8/8/8/8/4K3 w - z9 0 1",
+		"en passant on the wrong rank": "4k3/8/8/8/4P3/8/8/4K3 b - e4 0 1",
+		"en passant without a push":    "4k3/8/8/8/8/8/8/4K3 b - e3 0 1",
+		"a negative halfmove clock":    "4k3/8/8/8/8/8/8/4K3 w - - -1 1",
+		"a zero fullmove number":       "4k3/8/8/8/8/8/8/4K3 w - - 0 0",
+		"the side not to move checked": "4k2R/8/8/8/8/8/8/4K3 w - - 0 1",
+	}
+	for name, fen := range tests {
+		var board chessBoard
+		if err := board.loadFEN(fen); err == nil {
+			t.Errorf("loaded a FEN with %s: %q", name, fen)
+		}
+	}
+
+	// A rejected FEN leaves the board as it was
+	var board chessBoard
+	if err := board.loadFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+	if err := board.loadFEN("4k3/8/8/8/8/8/8/3KK3 w - - 0 1"); err == nil || board.toFEN() != StartingFEN {
+		t.Errorf("board is %q after a rejected FEN, want %q", board.toFEN(), StartingFEN)
+	}
+}
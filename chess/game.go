@@ -0,0 +1,432 @@
+// Package chess implements the rules of chess, PGN and FEN, and the It's Chess engine, with no user interface.
+// A Game is played by passing it Moves, and an Engine searches a Game for the best move.
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A side in a game.  The zero Color is neither side, the color of an empty square.
+type Color int
+
+const (
+	White = Color(white)
+	Black = Color(black)
+)
+
+// The other side.  Panics for the zero Color.
+func (c Color) Opponent() Color {
+	return Color(pieceColor(c).oppositeColor())
+}
+
+func (c Color) String() string {
+	return pieceColor(c).name()
+}
+
+// The type of a chess piece.  The zero PieceType is no piece.
+type PieceType int
+
+const (
+	Pawn   = PieceType(pawn)
+	Knight = PieceType(knight)
+	Bishop = PieceType(bishop)
+	Rook   = PieceType(rook)
+	Queen  = PieceType(queen)
+	King   = PieceType(king)
+)
+
+// A piece of a side.  The zero Piece is an empty square.
+type Piece struct {
+	Type  PieceType
+	Color Color
+}
+
+func pieceOf(cp chessPiece) Piece {
+	return Piece{PieceType(cp.pieceType), Color(cp.color)}
+}
+
+// A square of the board, from 0 for a1 to 63 for h8, rank by rank
+type Square int
+
+const NoSquare Square = -1
+
+// The square on a file and rank, both counted from 0
+func SquareAt(file int, rank int) Square {
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return NoSquare
+	}
+	return Square(rank*8 + file)
+}
+
+// Parses an algebraic square name such as "e4"
+func ParseSquare(name string) (Square, error) {
+	square, ok := algebraicToSquare(name)
+	if !ok {
+		return NoSquare, fmt.Errorf("%q is not a square", name)
+	}
+	return squareOf(square), nil
+}
+
+func squareOf(square vector2) Square {
+	return SquareAt(square.x, square.y)
+}
+
+// File of the square, 0 for the a-file
+func (s Square) File() int {
+	return int(s) % 8
+}
+
+// Rank of the square, 0 for the first rank
+func (s Square) Rank() int {
+	return int(s) / 8
+}
+
+// Whether the square is one of the board's 64
+func (s Square) onBoard() bool {
+	return s >= 0 && s < 64
+}
+
+func (s Square) vector() vector2 {
+	if s == NoSquare {
+		return nilSquare
+	}
+	return vector2{s.File(), s.Rank()}
+}
+
+func (s Square) String() string {
+	if !s.onBoard() {
+		return "-"
+	}
+	return squareToAlgebraic(s.vector())
+}
+
+// A move from one square to another.  Castling is the king's move to the square it ends up on, or in Chess960
+// the king's move onto its own rook.  The zero Move is no move.
+type Move struct {
+	From      Square
+	To        Square
+	Promotion PieceType // Piece a pawn promotes to, zero unless the move promotes
+}
+
+// Returns the Move for a board move.  In Chess960 the king can castle to a square it could also step to, so
+// castling is written as the king taking its own rook, as Chess960 UCI engines expect.
+func (cb *chessBoard) moveOf(move chessMove) Move {
+	if move.from == nilSquare {
+		return Move{}
+	}
+	if move.is(castlingFlag) && !cb.chess960 {
+		return move.castlingForms()[1]
+	}
+	return Move{squareOf(move.from), squareOf(move.to), PieceType(move.promotion)}
+}
+
+// Returns the move in long algebraic notation as used by UCI, e.g. "e2e4" or "e7e8q", or "0000" for no move
+func (m Move) String() string {
+	if m == (Move{}) {
+		return "0000"
+	}
+	notation := m.From.String() + m.To.String()
+	if m.Promotion != 0 {
+		notation += strings.ToLower(sanPieceLetters[piece(m.Promotion)])
+	}
+	return notation
+}
+
+// A game of chess: the position, the moves that led to it and how the game ended.  Moves that are taken back
+// can be replayed until a different move is played.
+type Game struct {
+	board     chessBoard
+	record    gameRecord
+	outcome   Outcome
+	undoStack []moveUndoState // Moves played, most recent last
+	redoStack []chessMove     // Moves taken back, most recent last
+}
+
+// Starts a game from the initial position
+func NewGame() *Game {
+	game, err := NewGameFromFEN(StartingFEN)
+	if err != nil {
+		panic(err)
+	}
+	return game
+}
+
+// Starts a game from the position described in Forsyth-Edwards Notation
+func NewGameFromFEN(fen string) (*Game, error) {
+	game := &Game{}
+	if err := game.board.loadFEN(fen); err != nil {
+		return nil, err
+	}
+	game.record = newGameRecord(game.board.toFEN())
+	if game.board.chess960 {
+		game.record.tags["Variant"] = chess960Variant
+	}
+	game.outcome = game.board.boardOutcome()
+	return game, nil
+}
+
+// Starts a game of Chess960 from one of its starting positions, numbered from 0 to 959 as by Scharnagl.
+// Position 518 is the standard starting position.
+func NewChess960Game(position int) (*Game, error) {
+	game := &Game{}
+	if err := game.board.initChess960(position); err != nil {
+		return nil, err
+	}
+	game.record = newGameRecord(game.board.toFEN())
+	game.record.tags["Variant"] = chess960Variant
+	game.outcome = game.board.boardOutcome()
+	return game, nil
+}
+
+// Continues the first game of a PGN text from its final position.  Its tags are kept for PGN export.
+func NewGameFromPGN(pgn string) (*Game, error) {
+	record, outcome, err := parsePGN(pgn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the game move by move so that its moves can be taken back
+	game := &Game{record: record, outcome: outcome}
+	if err := game.board.loadFEN(record.startFEN); err != nil {
+		return nil, err
+	}
+	game.board.chess960 = game.board.chess960 || isChess960Variant(record.tags["Variant"])
+	for _, move := range record.moves {
+		game.undoStack = append(game.undoStack, game.board.movePiece(move))
+	}
+	return game, nil
+}
+
+// The side to move
+func (g *Game) Turn() Color {
+	return Color(g.board.sideToMove)
+}
+
+// The piece on a square, the zero Piece if it is empty or off the board
+func (g *Game) Piece(square Square) Piece {
+	if !square.onBoard() {
+		return Piece{}
+	}
+	return pieceOf(g.board.getPiece(square.vector()))
+}
+
+// The position in Forsyth-Edwards Notation
+func (g *Game) FEN() string {
+	return g.board.toFEN()
+}
+
+// The position the game started from in Forsyth-Edwards Notation
+func (g *Game) StartFEN() string {
+	return g.record.startFEN
+}
+
+// The game so far in PGN
+func (g *Game) PGN() (string, error) {
+	return g.record.exportPGN(g.outcome)
+}
+
+// Sets a PGN tag, such as "White" or "Event", for the game's PGN export
+func (g *Game) SetTag(name string, value string) {
+	g.record.tags[name] = value
+}
+
+// The moves played since the start of the game
+func (g *Game) Moves() []Move {
+	moves := make([]Move, len(g.record.moves))
+	for i, move := range g.record.moves {
+		moves[i] = g.board.moveOf(move)
+	}
+	return moves
+}
+
+// The moves played since the start of the game in Standard Algebraic Notation
+func (g *Game) MovesSAN() []string {
+	var board chessBoard
+	board.loadFEN(g.record.startFEN)
+	sanMoves := make([]string, len(g.record.moves))
+	for i, move := range g.record.moves {
+		sanMoves[i] = board.moveToSAN(move)
+		board.movePiece(move)
+	}
+	return sanMoves
+}
+
+// The number of the current move, starting at 1 and incremented after each of black's moves
+func (g *Game) FullmoveNumber() int {
+	return g.board.fullmoveNumber
+}
+
+// The legal moves of the side to move, none once the game is over.  A promotion is listed once per piece the
+// pawn can promote to.
+func (g *Game) LegalMoves() []Move {
+	if g.outcome.IsOver() {
+		return nil
+	}
+	return g.board.movesOf(g.board.getAllValidMovesForPlayer(g.board.sideToMove))
+}
+
+// The legal moves of the piece on a square, none if it isn't the piece's turn
+func (g *Game) LegalMovesFrom(square Square) []Move {
+	if !square.onBoard() || g.outcome.IsOver() || g.board.getPiece(square.vector()).color != g.board.sideToMove {
+		return nil
+	}
+	return g.board.movesOf(g.board.getValidMoves(square.vector()))
+}
+
+func (cb *chessBoard) movesOf(chessMoves []chessMove) []Move {
+	moves := make([]Move, len(chessMoves))
+	for i, move := range chessMoves {
+		moves[i] = cb.moveOf(move)
+	}
+	return moves
+}
+
+// Returns the board's move for move if it is legal.  Castling may also be given as the king taking its own
+// rook, or in Chess960 as the king's move to its destination.
+func (g *Game) legalMove(move Move) (chessMove, error) {
+	if g.outcome.IsOver() {
+		return nilMove, fmt.Errorf("%s can't be played, the game is over", move)
+	}
+	if legal, ok := g.board.findLegalMove(move); ok {
+		return legal, nil
+	}
+	return nilMove, fmt.Errorf("%s is not a legal move for %s", move, g.board.sideToMove.name())
+}
+
+// Whether the game is Chess960
+func (g *Game) Chess960() bool {
+	return g.board.chess960
+}
+
+// Reports whether move is legal for the side to move.  Castling may be given as the king's move to its
+// destination or onto its own rook.
+func (g *Game) IsLegal(move Move) bool {
+	_, err := g.legalMove(move)
+	return err == nil
+}
+
+// Plays a legal move for the side to move.  A new move discards any moves that were taken back.
+func (g *Game) Play(move Move) error {
+	boardMove, err := g.legalMove(move)
+	if err != nil {
+		return err
+	}
+	g.makeMove(boardMove)
+	g.redoStack = nil
+	return nil
+}
+
+func (g *Game) makeMove(move chessMove) {
+	g.record.addMove(move)
+	g.undoStack = append(g.undoStack, g.board.movePiece(move))
+	g.outcome = g.board.boardOutcome()
+}
+
+// Parses a move for the side to move in SAN, such as "Nf3", or long algebraic notation, such as "g1f3"
+func (g *Game) ParseMove(text string) (Move, error) {
+	if move, err := g.board.parseMove(text); err == nil {
+		return g.board.moveOf(move), nil
+	}
+	move, err := g.board.sanToMove(text)
+	if err != nil {
+		return Move{}, err
+	}
+	return g.board.moveOf(move), nil
+}
+
+// Returns a legal move of the side to move in Standard Algebraic Notation, e.g. "Nf3" or "exd8=Q+"
+func (g *Game) SAN(move Move) (string, error) {
+	boardMove, err := g.legalMove(move)
+	if err != nil {
+		return "", err
+	}
+	return g.board.moveToSAN(boardMove), nil
+}
+
+// Takes back the last move played.  Taking back a move also withdraws a resignation, agreement or claim
+// made after it.  Returns the move, or false if there is none.
+func (g *Game) Undo() (Move, bool) {
+	if len(g.undoStack) == 0 {
+		return Move{}, false
+	}
+	undo := g.undoStack[len(g.undoStack)-1]
+	g.undoStack = g.undoStack[:len(g.undoStack)-1]
+
+	g.board.unmakeMove(undo)
+	g.record.removeLastMove()
+	g.redoStack = append(g.redoStack, undo.move)
+	g.outcome = g.board.boardOutcome()
+	return g.board.moveOf(undo.move), true
+}
+
+// Replays the last move taken back.  Returns the move, or false if there is none.
+func (g *Game) Redo() (Move, bool) {
+	if len(g.redoStack) == 0 {
+		return Move{}, false
+	}
+	move := g.redoStack[len(g.redoStack)-1]
+	g.redoStack = g.redoStack[:len(g.redoStack)-1]
+
+	g.makeMove(move)
+	return g.board.moveOf(move), true
+}
+
+// Whether the side to move is in check
+func (g *Game) InCheck() bool {
+	return g.board.playerInCheck(g.board.sideToMove)
+}
+
+// How the game ended.  Its result is Ongoing until it does.
+func (g *Game) Outcome() Outcome {
+	return g.outcome
+}
+
+// Shorthand for Outcome().Result()
+func (g *Game) Result() Result {
+	return g.outcome.result
+}
+
+// The draw the side to move may claim, by threefold repetition or the fifty-move rule, or NotTerminated
+func (g *Game) ClaimableDraw() Termination {
+	if g.outcome.IsOver() {
+		return NotTerminated
+	}
+	return g.board.claimableDrawTermination()
+}
+
+// Ends the game in a draw if the side to move is entitled to claim one.  Reports whether it did.
+func (g *Game) ClaimDraw() bool {
+	termination := g.ClaimableDraw()
+	if termination == NotTerminated {
+		return false
+	}
+	g.outcome = drawnOutcome(termination)
+	return true
+}
+
+// Ends the game in a draw by agreement
+func (g *Game) AgreeDraw() {
+	if !g.outcome.IsOver() {
+		g.outcome = drawnOutcome(Agreement)
+	}
+}
+
+// Ends the game with side having run out of time: a loss, or a draw if its opponent has no way to checkmate
+func (g *Game) TimeOut(side Color) {
+	if g.outcome.IsOver() {
+		return
+	}
+	if g.board.canCheckmate(pieceColor(side).oppositeColor()) {
+		g.outcome = decisiveOutcome(pieceColor(side), Timeout)
+	} else {
+		g.outcome = drawnOutcome(Timeout)
+	}
+}
+
+// Ends the game with side resigning, whether or not it is to move
+func (g *Game) Resign(side Color) {
+	if !g.outcome.IsOver() {
+		g.outcome = decisiveOutcome(pieceColor(side), Resignation)
+	}
+}
@@ -0,0 +1,134 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func playMoves(t *testing.T, game *Game, moves ...string) {
+	t.Helper()
+	for _, text := range moves {
+		move, err := game.ParseMove(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGameCheckmate(t *testing.T) {
+	game := NewGame()
+	if moves := game.LegalMoves(); len(moves) != 20 {
+		t.Fatalf("%d legal moves from the initial position, want 20", len(moves))
+	}
+
+	// Scholar's mate, mixing SAN and long algebraic notation
+	playMoves(t, game, "e4", "e7e5", "Bc4", "b8c6", "Qh5", "Nf6", "Qxf7#")
+
+	if result := game.Result(); result != WhiteWon {
+		t.Errorf("result = %v, want %v", result, WhiteWon)
+	}
+	if termination := game.Outcome().Termination(); termination != Checkmate {
+		t.Errorf("termination = %v, want %v", termination, Checkmate)
+	}
+	if moves := game.LegalMoves(); len(moves) != 0 {
+		t.Errorf("%d legal moves after checkmate, want none", len(moves))
+	}
+	if err := game.Play(Move{From: SquareAt(4, 7), To: SquareAt(4, 6)}); err == nil {
+		t.Error("played a move after checkmate")
+	}
+	if got, want := strings.Join(game.MovesSAN(), " "), "e4 e5 Bc4 Nc6 Qh5 Nf6 Qxf7#"; got != want {
+		t.Errorf("moves = %q, want %q", got, want)
+	}
+}
+
+func TestGameUndoRedo(t *testing.T) {
+	game := NewGame()
+	playMoves(t, game, "d4", "d5")
+	afterD5 := game.FEN()
+
+	undone, ok := game.Undo()
+	if !ok || undone.String() != "d7d5" {
+		t.Fatalf("Undo() = %v, %v, want d7d5, true", undone, ok)
+	}
+	if game.Turn() != Black {
+		t.Errorf("turn after undo = %v, want black", game.Turn())
+	}
+	if _, ok := game.Redo(); !ok || game.FEN() != afterD5 {
+		t.Errorf("redo gave %q, want %q", game.FEN(), afterD5)
+	}
+
+	// A new move discards the moves taken back
+	game.Undo()
+	playMoves(t, game, "Nf6")
+	if _, ok := game.Redo(); ok {
+		t.Error("redo after a new move")
+	}
+}
+
+func TestGamePGNRoundTrip(t *testing.T) {
+	game := NewGame()
+	playMoves(t, game, "e4", "c5", "Nf3", "d6")
+	game.Resign(game.Turn())
+
+	pgn, err := game.PGN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := NewGameFromPGN(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.FEN() != game.FEN() || imported.Result() != BlackWon {
+		t.Errorf("imported %q with result %v, want %q with result %v", imported.FEN(), imported.Result(), game.FEN(), BlackWon)
+	}
+}
+
+func TestSquare(t *testing.T) {
+	square, err := ParseSquare("e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if square.File() != 4 || square.Rank() != 3 || square != SquareAt(4, 3) || square.String() != "e4" {
+		t.Errorf("e4 parsed as file %d, rank %d, %s", square.File(), square.Rank(), square)
+	}
+	if _, err := ParseSquare("i9"); err == nil {
+		t.Error("parsed i9 as a square")
+	}
+
+	game := NewGame()
+	for _, square := range []Square{NoSquare, 64} {
+		if piece, moves := game.Piece(square), game.LegalMovesFrom(square); piece != (Piece{}) || moves != nil {
+			t.Errorf("square %d off the board holds %v with moves %v", square, piece, moves)
+		}
+	}
+}
+
+func TestParseLongAlgebraic(t *testing.T) {
+	e2, _ := ParseSquare("e2")
+	e4, _ := ParseSquare("e4")
+	e7, _ := ParseSquare("e7")
+	e8, _ := ParseSquare("e8")
+	tests := []struct {
+		notation string
+		want     Move
+		ok       bool
+	}{
+		{"e2e4", Move{From: e2, To: e4}, true},
+		{"e7e8q", Move{From: e7, To: e8, Promotion: Queen}, true},
+		{"e7e8n", Move{From: e7, To: e8, Promotion: Knight}, true},
+		{"e7e8k", Move{}, false},
+		{"e7e8p", Move{}, false},
+		{"e2e9", Move{}, false},
+		{"e2", Move{}, false},
+		{"e2e4qq", Move{}, false},
+	}
+	for _, test := range tests {
+		move, err := ParseLongAlgebraic(test.notation)
+		if (err == nil) != test.ok || move != test.want {
+			t.Errorf("ParseLongAlgebraic(%q) = %v, %v, want %v", test.notation, move, err, test.want)
+		}
+	}
+}
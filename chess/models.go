@@ -0,0 +1,34 @@
+package chess
+
+type piece int
+
+type pieceColor int
+
+var emptyPiece = chessPiece{empty, nocolor}
+var nilSquare = vector2{-1, -1}
+
+const (
+	nocolor pieceColor = iota
+	white
+	black
+)
+
+const (
+	empty piece = iota
+	pawn
+	bishop
+	knight
+	rook
+	queen
+	king
+)
+
+type chessPiece struct {
+	pieceType piece
+	color     pieceColor
+}
+
+type vector2 struct {
+	x int
+	y int
+}
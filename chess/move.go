@@ -0,0 +1,139 @@
+package chess
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type moveFlags uint8
+
+const (
+	captureFlag moveFlags = 1 << iota
+	enPassantFlag
+	castlingFlag
+	doublePawnPushFlag
+	promotionFlag
+)
+
+var promotionPieces = []piece{queen, rook, bishop, knight}
+
+type chessMove struct {
+	from          vector2
+	to            vector2
+	movedPiece    chessPiece
+	capturedPiece chessPiece
+	promotion     piece // empty unless the move is a promotion
+	flags         moveFlags
+}
+
+var nilMove = chessMove{from: nilSquare, to: nilSquare}
+
+func (m chessMove) is(flag moveFlags) bool {
+	return m.flags&flag != 0
+}
+
+// Builds a move from the current board state, filling in the moved and captured pieces and the move flags.
+// promotion is ignored unless the move takes a pawn to the last rank.
+func (cb *chessBoard) newMove(from vector2, to vector2, promotion piece) chessMove {
+	movedPiece := cb.getPiece(from)
+	move := chessMove{
+		from:          from,
+		to:            to,
+		movedPiece:    movedPiece,
+		capturedPiece: cb.getPiece(to),
+	}
+
+	if move.capturedPiece != emptyPiece {
+		move.flags |= captureFlag
+	}
+
+	switch movedPiece.pieceType {
+	case pawn:
+		if to == cb.enpassantSquare && from.x != to.x {
+			move.flags |= captureFlag | enPassantFlag
+			move.capturedPiece = cb.getPiece(vector2{to.x, from.y})
+		}
+		if abs(to.y-from.y) == 2 {
+			move.flags |= doublePawnPushFlag
+		}
+		if to.y == 0 || to.y == 7 {
+			move.flags |= promotionFlag
+			move.promotion = promotion
+		}
+	case king:
+		// Castling is written as the king taking its own rook, which no other king move can be mistaken for
+		if move.capturedPiece == (chessPiece{rook, movedPiece.color}) {
+			move.flags = castlingFlag
+			move.capturedPiece = emptyPiece
+		}
+	}
+
+	return move
+}
+
+// Returns the move in long algebraic notation, e.g. "e2e4" or "e7e8q".  Castling is written as the king
+// taking its own rook; cb.moveOf gives the form players expect.
+func (m chessMove) String() string {
+	notation := squareToAlgebraic(m.from) + squareToAlgebraic(m.to)
+	if m.is(promotionFlag) {
+		notation += strings.ToLower(sanPieceLetters[m.promotion])
+	}
+	return notation
+}
+
+// Both ways a castling move can be written: the king taking its own rook, and the king moving to the square
+// it ends up on
+func (m chessMove) castlingForms() []Move {
+	kingTarget, _ := castlingTargets(m.from, m.to)
+	return []Move{{From: squareOf(m.from), To: squareOf(m.to)}, {From: squareOf(m.from), To: squareOf(kingTarget)}}
+}
+
+// Returns the legal move of the side to move written as move, or false if there is none.  Castling may be
+// written either way, as long as the king's destination isn't also an ordinary king move.
+func (cb *chessBoard) findLegalMove(move Move) (chessMove, bool) {
+	legalMoves := cb.getAllValidMovesForPlayer(cb.sideToMove)
+	for _, legal := range legalMoves {
+		if cb.moveOf(legal) == move {
+			return legal, true
+		}
+	}
+	for _, legal := range legalMoves {
+		if legal.is(castlingFlag) && slices.Contains(legal.castlingForms(), move) {
+			return legal, true
+		}
+	}
+	return nilMove, false
+}
+
+// Resolves a move in long algebraic notation, as written by Move.String, into a legal move for the side to move
+func (cb *chessBoard) parseMove(notation string) (chessMove, error) {
+	if move, err := ParseLongAlgebraic(notation); err == nil {
+		if legal, ok := cb.findLegalMove(move); ok {
+			return legal, nil
+		}
+	}
+	return nilMove, fmt.Errorf("%q is not a legal move for %s", notation, cb.sideToMove.name())
+}
+
+// Parses a move in long algebraic notation, such as "e2e4" or "e7e8q", without checking it is legal in any
+// position
+func ParseLongAlgebraic(notation string) (Move, error) {
+	if len(notation) != 4 && len(notation) != 5 {
+		return Move{}, fmt.Errorf("%q is not a move", notation)
+	}
+	from, fromOK := algebraicToSquare(notation[:2])
+	to, toOK := algebraicToSquare(notation[2:4])
+	if !fromOK || !toOK {
+		return Move{}, fmt.Errorf("%q is not a move", notation)
+	}
+	move := Move{From: squareOf(from), To: squareOf(to)}
+	if len(notation) == 5 {
+		promotion, ok := pieceFromSANLetter(strings.ToUpper(notation[4:])[0])
+		if !ok || promotion == king || promotion == pawn {
+			return Move{}, fmt.Errorf("%q is not a move", notation)
+		}
+		move.Promotion = PieceType(promotion)
+	}
+	return move, nil
+}
@@ -0,0 +1,198 @@
+package chess
+
+func (cb *chessBoard) occupied() bitboard {
+	return cb.colorBitboards[white] | cb.colorBitboards[black]
+}
+
+// Returns the pieces of attackingColor that attack the square with the given index.  Sliding attacks are
+// blocked by the pieces in occupied, which lets callers look through pieces that are about to move.
+func (cb *chessBoard) attackersOf(index int, attackingColor pieceColor, occupied bitboard) bitboard {
+	attackers := &cb.pieceBitboards[attackingColor]
+	defendingColor := attackingColor.oppositeColor()
+
+	return pawnAttacks[defendingColor][index]&attackers[pawn] |
+		knightAttacks[index]&attackers[knight] |
+		kingAttacks[index]&attackers[king] |
+		rookAttacks(index, occupied)&(attackers[rook]|attackers[queen]) |
+		bishopAttacks(index, occupied)&(attackers[bishop]|attackers[queen])
+}
+
+// Appends the legal moves of playerColor to moves.  Instead of trying every move on a copy of the board,
+// moves are restricted up front: when in check, to capturing the checker or blocking its line, and for
+// pinned pieces, to the line between their king and the pinning piece.
+func (cb *chessBoard) generateLegalMoves(playerColor pieceColor, moves []chessMove) []chessMove {
+	opponentColor := playerColor.oppositeColor()
+	own := cb.colorBitboards[playerColor]
+	opponent := cb.colorBitboards[opponentColor]
+	occupied := own | opponent
+	opponentPieces := &cb.pieceBitboards[opponentColor]
+
+	kingIndex := cb.kingIndex(playerColor)
+	checkers := cb.attackersOf(kingIndex, opponentColor, occupied)
+
+	// The king can't step along the line of a slider checking it, so it is taken off the board when
+	// testing its target squares
+	kingTargets := kingAttacks[kingIndex] &^ own
+	for kingTargets != 0 {
+		target := kingTargets.popFirst()
+		if cb.attackersOf(target, opponentColor, occupied&^squareBitboard(kingIndex)) == 0 {
+			moves = cb.appendMove(moves, kingIndex, target)
+		}
+	}
+
+	// Only the king can escape a double check
+	if checkers.count() > 1 {
+		return moves
+	}
+
+	// Squares other pieces may move to
+	checkMask := allSquares
+	if checkers != 0 {
+		checker := checkers.first()
+		checkMask = checkers | betweenSquares[kingIndex][checker]
+	} else {
+		moves = cb.appendCastlingMoves(moves, playerColor, kingIndex, occupied)
+	}
+
+	// A piece is pinned when it is the only piece between its king and an opponent slider aimed at the king
+	pinned := bitboard(0)
+	snipers := rookAttacks(kingIndex, opponent)&(opponentPieces[rook]|opponentPieces[queen]) |
+		bishopAttacks(kingIndex, opponent)&(opponentPieces[bishop]|opponentPieces[queen])
+	for snipers != 0 {
+		sniper := snipers.popFirst()
+		blockers := betweenSquares[kingIndex][sniper] & occupied
+		if blockers.count() == 1 && blockers&own != 0 {
+			pinned |= blockers
+		}
+	}
+
+	// Legal target squares for the piece on from, given the squares it could reach on an empty check mask
+	legalTargets := func(from int, targets bitboard) bitboard {
+		targets &= checkMask
+		if pinned.has(from) {
+			targets &= lineSquares[kingIndex][from]
+		}
+		return targets
+	}
+
+	ownPieces := &cb.pieceBitboards[playerColor]
+
+	knights := ownPieces[knight] &^ pinned // A pinned knight can never stay on the pin line
+	for knights != 0 {
+		from := knights.popFirst()
+		moves = cb.appendMoves(moves, from, legalTargets(from, knightAttacks[from]&^own))
+	}
+
+	rookMovers := ownPieces[rook] | ownPieces[queen]
+	for rookMovers != 0 {
+		from := rookMovers.popFirst()
+		moves = cb.appendMoves(moves, from, legalTargets(from, rookAttacks(from, occupied)&^own))
+	}
+
+	bishopMovers := ownPieces[bishop] | ownPieces[queen]
+	for bishopMovers != 0 {
+		from := bishopMovers.popFirst()
+		moves = cb.appendMoves(moves, from, legalTargets(from, bishopAttacks(from, occupied)&^own))
+	}
+
+	// Pawns
+	forward, startRank := 8, 1
+	if playerColor == black {
+		forward, startRank = -8, 6
+	}
+	enPassantIndex := -1
+	if cb.enpassantSquare != nilSquare && playerColor == cb.sideToMove {
+		enPassantIndex = squareIndex(cb.enpassantSquare)
+	}
+
+	pawns := ownPieces[pawn]
+	for pawns != 0 {
+		from := pawns.popFirst()
+
+		targets := pawnAttacks[playerColor][from] & opponent
+		if push := from + forward; !occupied.has(push) {
+			targets |= squareBitboard(push)
+			if doublePush := push + forward; from/8 == startRank && !occupied.has(doublePush) {
+				targets |= squareBitboard(doublePush)
+			}
+		}
+		moves = cb.appendMoves(moves, from, legalTargets(from, targets))
+
+		if enPassantIndex != -1 && pawnAttacks[playerColor][from].has(enPassantIndex) &&
+			cb.enPassantIsLegal(playerColor, kingIndex, from, enPassantIndex) {
+			moves = cb.appendMove(moves, from, enPassantIndex)
+		}
+	}
+
+	return moves
+}
+
+// En passant removes two pieces from the same rank, which can expose the king in ways the pin and check
+// masks don't cover, so the capture is tried out on the occupancy instead
+func (cb *chessBoard) enPassantIsLegal(playerColor pieceColor, kingIndex int, from int, target int) bool {
+	capturedIndex := target - 8
+	if playerColor == black {
+		capturedIndex = target + 8
+	}
+
+	occupied := cb.occupied()&^squareBitboard(from)&^squareBitboard(capturedIndex) | squareBitboard(target)
+	attackers := cb.attackersOf(kingIndex, playerColor.oppositeColor(), occupied)
+	return attackers&occupied == 0
+}
+
+// Appends the castling moves of playerColor, who must not be in check.  Castling works the same way for
+// any starting files: the king and rook end up on the g- and f-files or the c- and d-files.
+func (cb *chessBoard) appendCastlingMoves(moves []chessMove, playerColor pieceColor, kingIndex int, occupied bitboard) []chessMove {
+	opponentColor := playerColor.oppositeColor()
+	kingSquare := indexToSquare(kingIndex)
+
+	rooks := cb.castlingRooks & cb.pieceBitboards[playerColor][rook]
+	for rooks != 0 {
+		rookIndex := rooks.popFirst()
+		kingTarget, rookTarget := castlingTargets(kingSquare, indexToSquare(rookIndex))
+		kingTargetIndex, rookTargetIndex := squareIndex(kingTarget), squareIndex(rookTarget)
+
+		// Every square the king and rook cross or land on must be empty, apart from the two of them
+		castlers := squareBitboard(kingIndex) | squareBitboard(rookIndex)
+		path := betweenSquares[kingIndex][kingTargetIndex] | betweenSquares[rookIndex][rookTargetIndex] |
+			squareBitboard(kingTargetIndex) | squareBitboard(rookTargetIndex)
+		if path&occupied&^castlers != 0 {
+			continue
+		}
+
+		// The squares the king crosses and lands on must be unattacked.  The rook is taken off the board, as
+		// it may be shielding the king's target square along the rank.
+		kingPath := betweenSquares[kingIndex][kingTargetIndex] | squareBitboard(kingTargetIndex)
+		attacked := false
+		for kingPath != 0 && !attacked {
+			attacked = cb.attackersOf(kingPath.popFirst(), opponentColor, occupied&^squareBitboard(rookIndex)) != 0
+		}
+		if !attacked {
+			moves = cb.appendMove(moves, kingIndex, rookIndex)
+		}
+	}
+
+	return moves
+}
+
+// Appends a move from the square index from to every square in targets
+func (cb *chessBoard) appendMoves(moves []chessMove, from int, targets bitboard) []chessMove {
+	for targets != 0 {
+		moves = cb.appendMove(moves, from, targets.popFirst())
+	}
+	return moves
+}
+
+// Appends the move between two square indexes, once per promotion piece if it promotes
+func (cb *chessBoard) appendMove(moves []chessMove, from int, to int) []chessMove {
+	move := cb.newMove(indexToSquare(from), indexToSquare(to), empty)
+	if !move.is(promotionFlag) {
+		return append(moves, move)
+	}
+
+	for _, promotion := range promotionPieces {
+		move.promotion = promotion
+		moves = append(moves, move)
+	}
+	return moves
+}
@@ -0,0 +1,147 @@
+package chess
+
+import "fmt"
+
+// The result of a game: ongoing or who won
+type Result int
+
+const (
+	Ongoing Result = iota
+	WhiteWon
+	BlackWon
+	Drawn
+)
+
+// Returns the result in PGN notation
+func (r Result) String() string {
+	switch r {
+	case WhiteWon:
+		return "1-0"
+	case BlackWon:
+		return "0-1"
+	case Drawn:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+func parseGameResult(symbol string) (Result, bool) {
+	for _, result := range []Result{Ongoing, WhiteWon, BlackWon, Drawn} {
+		if result.String() == symbol {
+			return result, true
+		}
+	}
+	return Ongoing, false
+}
+
+// The reason a game ended
+type Termination int
+
+const (
+	NotTerminated Termination = iota
+	Checkmate
+	Stalemate
+	Resignation
+	Timeout
+	Agreement
+	Repetition
+	FiftyMoveRule
+	InsufficientMaterial
+	UnknownTermination // Finished games imported without a known cause
+)
+
+func (t Termination) String() string {
+	switch t {
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case Resignation:
+		return "resignation"
+	case Timeout:
+		return "timeout"
+	case Agreement:
+		return "agreement"
+	case Repetition:
+		return "repetition"
+	case FiftyMoveRule:
+		return "fifty-move rule"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case UnknownTermination:
+		return "unknown termination"
+	}
+	return "not terminated"
+}
+
+// How a game ended, or that it hasn't
+type Outcome struct {
+	result      Result
+	termination Termination
+}
+
+var ongoingOutcome = Outcome{Ongoing, NotTerminated}
+
+func (o Outcome) Result() Result {
+	return o.result
+}
+
+func (o Outcome) Termination() Termination {
+	return o.termination
+}
+
+func (o Outcome) IsOver() bool {
+	return o.result != Ongoing
+}
+
+// A win for the opponent of loser
+func decisiveOutcome(loser pieceColor, termination Termination) Outcome {
+	if loser == white {
+		return Outcome{BlackWon, termination}
+	}
+	return Outcome{WhiteWon, termination}
+}
+
+func drawnOutcome(termination Termination) Outcome {
+	return Outcome{Drawn, termination}
+}
+
+// Describes the outcome for the player, e.g. "White Won the Game!" or "Draw by stalemate"
+func (o Outcome) String() string {
+	switch o.result {
+	case WhiteWon:
+		return "White Won the Game!"
+	case BlackWon:
+		return "Black Won the Game!"
+	case Drawn:
+		if o.termination == UnknownTermination {
+			return "Draw"
+		}
+		return fmt.Sprintf("Draw by %s", o.termination)
+	}
+	return ""
+}
+
+// Value of the PGN Termination tag
+func (o Outcome) pgnTermination() string {
+	switch o.termination {
+	case NotTerminated:
+		return "unterminated"
+	case Timeout:
+		return "time forfeit"
+	case UnknownTermination:
+		return "?"
+	}
+	return "normal"
+}
+
+// Returns how the position on the board ends the game, if it does: checkmate or a draw that needs no claim
+func (cb *chessBoard) boardOutcome() Outcome {
+	if cb.playerInCheckMate(cb.sideToMove) {
+		return decisiveOutcome(cb.sideToMove, Checkmate)
+	}
+	if termination := cb.automaticDrawTermination(); termination != NotTerminated {
+		return drawnOutcome(termination)
+	}
+	return ongoingOutcome
+}
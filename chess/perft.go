@@ -0,0 +1,72 @@
+package chess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Counts the leaf nodes of the legal move tree depth plies below the current position.  Comparing the
+// counts with published values is the standard way to find move generation bugs.
+func (cb *chessBoard) perft(depth int) int {
+	// Work on a copy without a position history so the walk doesn't build a repetition key per move
+	board := cb.deepCopy()
+	return board.perftNodes(depth, make([][]chessMove, depth+1))
+}
+
+// moveLists holds a move list per remaining depth, reused across the walk to avoid allocating at every node
+func (cb *chessBoard) perftNodes(depth int, moveLists [][]chessMove) int {
+	if depth == 0 {
+		return 1
+	}
+
+	moves := cb.generateLegalMoves(cb.sideToMove, moveLists[depth][:0])
+	moveLists[depth] = moves
+	if depth == 1 {
+		return len(moves)
+	}
+
+	nodes := 0
+	for _, move := range moves {
+		undo := cb.movePiece(move)
+		nodes += cb.perftNodes(depth-1, moveLists)
+		cb.unmakeMove(undo)
+	}
+	return nodes
+}
+
+// Splits the perft count of the current position by root move, keyed by the move in long algebraic notation
+func (cb *chessBoard) divide(depth int) map[string]int {
+	board := cb.deepCopy()
+
+	counts := map[string]int{}
+	if depth < 1 {
+		return counts
+	}
+	moveLists := make([][]chessMove, depth)
+	for _, move := range board.getAllValidMovesForPlayer(board.sideToMove) {
+		undo := board.movePiece(move)
+		counts[move.String()] = board.perftNodes(depth-1, moveLists)
+		board.unmakeMove(undo)
+	}
+	return counts
+}
+
+// Formats divide counts one root move per line, sorted, followed by the total.  The output matches the
+// "go perft" format of Stockfish so the two can be diffed.
+func formatDivide(counts map[string]int) string {
+	moves := make([]string, 0, len(counts))
+	total := 0
+	for move, nodes := range counts {
+		moves = append(moves, move)
+		total += nodes
+	}
+	sort.Strings(moves)
+
+	var output strings.Builder
+	for _, move := range moves {
+		fmt.Fprintf(&output, "%s: %d\n", move, counts[move])
+	}
+	fmt.Fprintf(&output, "\nNodes searched: %d\n", total)
+	return output.String()
+}
@@ -0,0 +1,141 @@
+package chess
+
+import "testing"
+
+// Reference positions and node counts from https://www.chessprogramming.org/Perft_Results
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []int // nodes[i] is the perft count at depth i+1
+}{
+	{
+		name:  "initial position",
+		fen:   StartingFEN,
+		nodes: []int{20, 400, 8902, 197281, 4865609},
+	},
+	{
+		name:  "kiwipete",
+		fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		nodes: []int{48, 2039, 97862, 4085603},
+	},
+	{
+		name:  "position 3",
+		fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		nodes: []int{14, 191, 2812, 43238, 674624},
+	},
+	{
+		name:  "position 4",
+		fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		nodes: []int{6, 264, 9467, 422333},
+	},
+	{
+		name:  "position 4 mirrored",
+		fen:   "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		nodes: []int{6, 264, 9467, 422333},
+	},
+	{
+		name:  "position 5",
+		fen:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		nodes: []int{44, 1486, 62379, 2103487},
+	},
+	{
+		name:  "position 6",
+		fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		nodes: []int{46, 2079, 89890, 3894594},
+	},
+	// The first Chess960 positions from https://www.chessprogramming.org/Chess960_Perft_Results
+	{
+		name:  "chess960 shredder-fen",
+		fen:   "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+		nodes: []int{21, 528, 12189, 326672},
+	},
+	{
+		name:  "chess960 adjacent rooks",
+		fen:   "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9",
+		nodes: []int{20, 479, 10471, 273318},
+	},
+	{
+		name:  "chess960 rooks on the e and h files",
+		fen:   "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9",
+		nodes: []int{21, 807, 18002, 667366},
+	},
+	{
+		name:  "chess960 white king moved",
+		fen:   "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9",
+		nodes: []int{22, 593, 13440, 382958},
+	},
+	{
+		name:  "chess960 black queen in the center",
+		fen:   "1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9",
+		nodes: []int{28, 1120, 31058, 1171749},
+	},
+}
+
+// Deeper counts take too long for every run, so -short stops at this many nodes
+const perftShortNodeLimit = 100000
+
+func TestPerft(t *testing.T) {
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			var board chessBoard
+			if err := board.loadFEN(position.fen); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expected := range position.nodes {
+				depth := i + 1
+				if testing.Short() && expected > perftShortNodeLimit {
+					break
+				}
+				if nodes := board.perft(depth); nodes != expected {
+					t.Fatalf("perft(%d) = %d, want %d\ndivide(%d):\n%s", depth, nodes, expected, depth, formatDivide(board.divide(depth)))
+				}
+			}
+		})
+	}
+}
+
+func TestPerftLeavesBoardUnchanged(t *testing.T) {
+	for _, position := range perftPositions {
+		var board chessBoard
+		if err := board.loadFEN(position.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		board.perft(2)
+		if fen := board.toFEN(); fen != position.fen {
+			t.Errorf("perft changed %q to %q", position.fen, fen)
+		}
+	}
+}
+
+func TestDivide(t *testing.T) {
+	var board chessBoard
+	if err := board.loadFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := board.divide(2)
+	if len(counts) != 20 {
+		t.Fatalf("divide(2) has %d root moves, want 20", len(counts))
+	}
+	for move, nodes := range counts {
+		if nodes != 20 {
+			t.Errorf("divide(2)[%s] = %d, want 20", move, nodes)
+		}
+	}
+}
+
+// Reports move generation speed in nodes per second, e.g. go test -bench Perft -run ^$
+func BenchmarkPerft(b *testing.B) {
+	var board chessBoard
+	if err := board.loadFEN(perftPositions[1].fen); err != nil {
+		b.Fatal(err)
+	}
+
+	nodes := 0
+	for range b.N {
+		nodes += board.perft(4)
+	}
+	b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+}
@@ -0,0 +1,345 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The Seven Tag Roster, in the order PGN requires them to be exported
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+const pgnLineWidth = 80
+
+// A move number, with the dots after it.  Zeros that start castling such as 0-0 aren't one.
+var moveNumberPattern = regexp.MustCompile(`^[0-9]+(\.+|$)`)
+
+// The Variant tag of Chess960 games
+const chess960Variant = "Chess960"
+
+// Whether a Variant tag names Chess960, which some tools call Fischerandom
+func isChess960Variant(variant string) bool {
+	switch strings.ToLower(strings.ReplaceAll(variant, " ", "")) {
+	case "chess960", "fischerandom", "fischerrandom":
+		return true
+	}
+	return false
+}
+
+type gameRecord struct {
+	tags     map[string]string
+	startFEN string
+	moves    []chessMove
+}
+
+func newGameRecord(startFEN string) gameRecord {
+	return gameRecord{
+		tags: map[string]string{
+			"Event": "Casual game",
+			"Site":  "It's Chess",
+			"Date":  time.Now().Format("2006.01.02"),
+			"Round": "-",
+			"White": "?",
+			"Black": "?",
+		},
+		startFEN: startFEN,
+	}
+}
+
+func (gr *gameRecord) addMove(move chessMove) {
+	gr.moves = append(gr.moves, move)
+}
+
+func (gr *gameRecord) removeLastMove() {
+	if len(gr.moves) > 0 {
+		gr.moves = gr.moves[:len(gr.moves)-1]
+	}
+}
+
+// Exports the game as PGN, taking the Result and Termination tags from outcome
+func (gr *gameRecord) exportPGN(outcome Outcome) (string, error) {
+	var board chessBoard
+	if err := board.loadFEN(gr.startFEN); err != nil {
+		return "", err
+	}
+
+	// Movetext
+	var tokens []string
+	for i, move := range gr.moves {
+		if board.sideToMove == white {
+			tokens = append(tokens, fmt.Sprintf("%d.", board.fullmoveNumber))
+		} else if i == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d...", board.fullmoveNumber))
+		}
+		tokens = append(tokens, board.moveToSAN(move))
+		board.movePiece(move)
+	}
+
+	tokens = append(tokens, outcome.result.String())
+
+	tags := make(map[string]string, len(gr.tags)+2)
+	for name, value := range gr.tags {
+		tags[name] = value
+	}
+	tags["Result"] = outcome.result.String()
+	tags["Termination"] = outcome.pgnTermination()
+	if gr.startFEN != StartingFEN {
+		tags["SetUp"] = "1"
+		tags["FEN"] = gr.startFEN
+	}
+
+	var pgn strings.Builder
+
+	// Tag pairs: Seven Tag Roster first, then the rest alphabetically
+	for _, name := range sevenTagRoster {
+		writePGNTag(&pgn, name, tags[name])
+		delete(tags, name)
+	}
+	var otherTags []string
+	for name := range tags {
+		otherTags = append(otherTags, name)
+	}
+	sort.Strings(otherTags)
+	for _, name := range otherTags {
+		writePGNTag(&pgn, name, tags[name])
+	}
+	pgn.WriteString("\n")
+
+	lineLength := 0
+	for _, token := range tokens {
+		if lineLength > 0 && lineLength+1+len(token) > pgnLineWidth {
+			pgn.WriteString("\n")
+			lineLength = 0
+		}
+		if lineLength > 0 {
+			pgn.WriteString(" ")
+			lineLength++
+		}
+		pgn.WriteString(token)
+		lineLength += len(token)
+	}
+	pgn.WriteString("\n")
+
+	return pgn.String(), nil
+}
+
+func writePGNTag(pgn *strings.Builder, name string, value string) {
+	if value == "" {
+		value = "?"
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(pgn, "[%s \"%s\"]\n", name, value)
+}
+
+type pgnTokenKind int
+
+const (
+	pgnTagToken pgnTokenKind = iota
+	pgnSymbolToken
+	pgnVariationStartToken
+	pgnVariationEndToken
+)
+
+type pgnToken struct {
+	kind  pgnTokenKind
+	text  string
+	value string // Only set for tag tokens
+}
+
+// Splits PGN text into tags and movetext symbols.  Comments, NAGs and escaped lines are dropped.
+func tokenizePGN(pgn string) ([]pgnToken, error) {
+	var tokens []pgnToken
+
+	lineStart := true
+	for i := 0; i < len(pgn); {
+		char := pgn[i]
+
+		// Escape mechanism: lines starting with % are ignored
+		if lineStart && char == '%' {
+			for i < len(pgn) && pgn[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		lineStart = char == '\n'
+
+		switch {
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			i++
+		case char == '{':
+			end := strings.IndexByte(pgn[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid PGN: unterminated comment")
+			}
+			i += end + 1
+		case char == ';':
+			for i < len(pgn) && pgn[i] != '\n' {
+				i++
+			}
+		case char == '(':
+			tokens = append(tokens, pgnToken{kind: pgnVariationStartToken, text: "("})
+			i++
+		case char == ')':
+			tokens = append(tokens, pgnToken{kind: pgnVariationEndToken, text: ")"})
+			i++
+		case char == '[':
+			tag, length, err := parsePGNTag(pgn[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tag)
+			i += length
+		case char == '}' || char == ']':
+			return nil, fmt.Errorf("invalid PGN: unmatched '%c'", char)
+		case char == '$':
+			// Numeric Annotation Glyph
+			i++
+			for i < len(pgn) && pgn[i] >= '0' && pgn[i] <= '9' {
+				i++
+			}
+		default:
+			start := i
+			for i < len(pgn) && !strings.ContainsRune(" \t\r\n{};()[]$", rune(pgn[i])) {
+				i++
+			}
+			tokens = append(tokens, pgnToken{kind: pgnSymbolToken, text: pgn[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+// Parses a [Name "Value"] tag pair at the start of text, returning its token and length
+func parsePGNTag(text string) (pgnToken, int, error) {
+	i := 1
+	for i < len(text) && text[i] == ' ' {
+		i++
+	}
+	nameStart := i
+	for i < len(text) && text[i] != ' ' && text[i] != '"' && text[i] != ']' {
+		i++
+	}
+	name := text[nameStart:i]
+	for i < len(text) && text[i] == ' ' {
+		i++
+	}
+	if name == "" || i >= len(text) || text[i] != '"' {
+		return pgnToken{}, 0, fmt.Errorf("invalid PGN: malformed tag pair near %q", firstLine(text))
+	}
+	i++
+
+	var value strings.Builder
+	for ; i < len(text) && text[i] != '"'; i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+		}
+		value.WriteByte(text[i])
+	}
+	if i >= len(text) {
+		return pgnToken{}, 0, fmt.Errorf("invalid PGN: unterminated value in tag %s", name)
+	}
+	i++
+	for i < len(text) && text[i] == ' ' {
+		i++
+	}
+	if i >= len(text) || text[i] != ']' {
+		return pgnToken{}, 0, fmt.Errorf("invalid PGN: unterminated tag %s", name)
+	}
+
+	return pgnToken{kind: pgnTagToken, text: name, value: value.String()}, i + 1, nil
+}
+
+func firstLine(text string) string {
+	if end := strings.IndexByte(text, '\n'); end != -1 {
+		return text[:end]
+	}
+	return text
+}
+
+// Parses the first game of a PGN text, replaying its main line.  Variations are skipped.  The outcome comes
+// from the final position when it ends the game, otherwise from the game's result.
+func parsePGN(pgn string) (gameRecord, Outcome, error) {
+	var board chessBoard
+
+	tokens, err := tokenizePGN(pgn)
+	if err != nil {
+		return gameRecord{}, ongoingOutcome, err
+	}
+
+	record := gameRecord{tags: map[string]string{}, startFEN: StartingFEN}
+
+	// Tag pair section
+	i := 0
+	for ; i < len(tokens) && tokens[i].kind == pgnTagToken; i++ {
+		record.tags[tokens[i].text] = tokens[i].value
+	}
+	if fen, ok := record.tags["FEN"]; ok {
+		record.startFEN = fen
+		delete(record.tags, "FEN")
+		delete(record.tags, "SetUp")
+	}
+	if err := board.loadFEN(record.startFEN); err != nil {
+		return gameRecord{}, ongoingOutcome, err
+	}
+
+	// Movetext section
+	variationDepth := 0
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.kind {
+		case pgnTagToken:
+			return gameRecord{}, ongoingOutcome, fmt.Errorf("invalid PGN: tag %s found inside movetext", token.text)
+		case pgnVariationStartToken:
+			variationDepth++
+			continue
+		case pgnVariationEndToken:
+			if variationDepth == 0 {
+				return gameRecord{}, ongoingOutcome, fmt.Errorf("invalid PGN: unmatched ')'")
+			}
+			variationDepth--
+			continue
+		}
+		if variationDepth > 0 {
+			continue
+		}
+
+		symbol := token.text
+		if result, ok := parseGameResult(symbol); ok {
+			record.tags["Result"] = result.String()
+			break
+		}
+
+		// Strip a leading move number indication such as "12." or "12..."
+		symbol = moveNumberPattern.ReplaceAllString(symbol, "")
+		// En passant annotations written as a separate symbol, e.g. "exd6 e.p."
+		if symbol == "" || enPassantSuffixPattern.MatchString(symbol) {
+			continue
+		}
+
+		move, err := board.sanToMove(symbol)
+		if err != nil {
+			return gameRecord{}, ongoingOutcome, fmt.Errorf("invalid PGN: move %d: %w", len(record.moves)+1, err)
+		}
+		board.movePiece(move)
+		record.addMove(move)
+	}
+
+	if variationDepth > 0 {
+		return gameRecord{}, ongoingOutcome, fmt.Errorf("invalid PGN: unterminated variation")
+	}
+
+	outcome := board.boardOutcome()
+	if result, _ := parseGameResult(record.tags["Result"]); !outcome.IsOver() && result != Ongoing {
+		outcome = Outcome{result, UnknownTermination}
+		if strings.EqualFold(record.tags["Termination"], "time forfeit") {
+			outcome.termination = Timeout
+		}
+	}
+	delete(record.tags, "Result")
+	delete(record.tags, "Termination")
+
+	return record, outcome, nil
+}
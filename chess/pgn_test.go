@@ -0,0 +1,73 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePGNAnnotations(t *testing.T) {
+	pgn := `[Event "Annotated game"]
+[White "Anderssen"]
+[Black "Kieseritzky"]
+[Result "1-0"]
+
+% An escaped line 1. d4 is not a move
+{Comments may hold (parentheses) and moves like 1. d4} 1. e4 $1 e5 $10 2. f4 {the King's Gambit}
+exf4 ; a comment to the end of the line ( 2... d5
+3. Bc4 $2 (3. Nf3 {the usual move} g5 (3... d6 $5 (3... Nf6)) 4. h4) Qh4+ 4. Kf1 $14
+(4. g3 fxg3 (4... Qe7) 5. Qf3) b5 1-0`
+
+	record, outcome, err := parsePGN(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Result() != WhiteWon || record.tags["Event"] != "Annotated game" || record.tags["Black"] != "Kieseritzky" {
+		t.Errorf("imported result %v and tags %v", outcome.Result(), record.tags)
+	}
+
+	var board chessBoard
+	if err := board.loadFEN(record.startFEN); err != nil {
+		t.Fatal(err)
+	}
+	var san []string
+	for _, move := range record.moves {
+		san = append(san, board.moveToSAN(move))
+		board.movePiece(move)
+	}
+	if got, want := strings.Join(san, " "), "e4 e5 f4 exf4 Bc4 Qh4+ Kf1 b5"; got != want {
+		t.Errorf("main line is %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{
+		"1. e4 {an unterminated comment",
+		"1. e4 e5 ) 2. Nf3",
+		"1. e4 ] e5 *",
+		"1. e4 } e5 *",
+		"1. e4 (1. d4 (1. c4) e5",
+		"1. e4 e5 2. Ke3",
+	} {
+		if _, _, err := parsePGN(bad); err == nil {
+			t.Errorf("parsed %q", bad)
+		}
+	}
+}
+
+// Castling may be written with zeros, which aren't a move number
+func TestParsePGNZeroCastling(t *testing.T) {
+	record, _, err := parsePGN("1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. d3 d6 5. Be3 Qe7 6. Nc3 Be6 7. 0-0 0-0-0 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var board chessBoard
+	if err := board.loadFEN(record.startFEN); err != nil {
+		t.Fatal(err)
+	}
+	var san []string
+	for _, move := range record.moves {
+		san = append(san, board.moveToSAN(move))
+		board.movePiece(move)
+	}
+	if got, want := strings.Join(san[len(san)-2:], " "), "O-O O-O-O"; got != want {
+		t.Errorf("castling moves read as %q, want %q", got, want)
+	}
+}
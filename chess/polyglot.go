@@ -0,0 +1,216 @@
+package chess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// Size in bytes of an entry of a Polyglot book
+const polyglotEntrySize = 16
+
+// Offsets into polyglotRandom of the keys that don't come from pieces
+const (
+	polyglotCastlingOffset  = 768
+	polyglotEnPassantOffset = 772
+	polyglotTurnOffset      = 780
+)
+
+// Polyglot orders piece kinds pawn, knight, bishop, rook, queen, king, with black's before white's
+var polyglotPieceKinds = [7]int{pawn: 0, knight: 1, bishop: 2, rook: 3, queen: 4, king: 5}
+
+// Promotion pieces as numbered in Polyglot moves
+var polyglotPromotions = [8]piece{1: knight, 2: bishop, 3: rook, 4: queen}
+
+// How a move is chosen from an opening book
+type BookSelection int
+
+const (
+	WeightedBookMove BookSelection = iota // At random, in proportion to the weights of the moves
+	BestBookMove                          // The move with the highest weight
+)
+
+// An opening book in the Polyglot format, giving the moves to play in known positions
+type OpeningBook struct {
+	entries []polyglotEntry // Sorted by key
+}
+
+type polyglotEntry struct {
+	key    uint64
+	move   uint16
+	weight uint16
+}
+
+// A move of an opening book.  Its weight is only meaningful next to the weights of the position's other moves.
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+// Reads the Polyglot book at path
+func LoadOpeningBook(path string) (*OpeningBook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	book, err := ReadOpeningBook(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return book, nil
+}
+
+// Reads a Polyglot book: 16 byte big-endian entries of a position key, a move, a weight and 4 bytes of
+// learning data, which is ignored
+func ReadOpeningBook(r io.Reader) (*OpeningBook, error) {
+	book := &OpeningBook{}
+	var entry [polyglotEntrySize]byte
+	for {
+		if _, err := io.ReadFull(r, entry[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("invalid Polyglot book: its size is not a multiple of %d bytes", polyglotEntrySize)
+			}
+			return nil, err
+		}
+		book.entries = append(book.entries, polyglotEntry{
+			key:    binary.BigEndian.Uint64(entry[0:8]),
+			move:   binary.BigEndian.Uint16(entry[8:10]),
+			weight: binary.BigEndian.Uint16(entry[10:12]),
+		})
+	}
+
+	// Books are written sorted, but nothing is lost by making sure
+	sort.SliceStable(book.entries, func(i, j int) bool { return book.entries[i].key < book.entries[j].key })
+	return book, nil
+}
+
+// The book's legal moves in the game's current position, highest weight first.  None once the game is over.
+func (b *OpeningBook) Moves(game *Game) []BookMove {
+	if game.outcome.IsOver() {
+		return nil
+	}
+	var moves []BookMove
+	for _, move := range b.boardMoves(&game.board) {
+		moves = append(moves, BookMove{game.board.moveOf(move.move), move.weight})
+	}
+	return moves
+}
+
+// Picks one of the book's moves in the game's current position, or returns false if it has none
+func (b *OpeningBook) ChooseMove(game *Game, selection BookSelection) (Move, bool) {
+	if game.outcome.IsOver() {
+		return Move{}, false
+	}
+	move, ok := b.chooseBoardMove(&game.board, selection)
+	return game.board.moveOf(move), ok
+}
+
+type weightedMove struct {
+	move   chessMove
+	weight int
+}
+
+// The book's legal moves in the board's position, highest weight first
+func (b *OpeningBook) boardMoves(board *chessBoard) []weightedMove {
+	key := board.polyglotKey()
+	first := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].key >= key })
+
+	legalMoves := board.getAllValidMovesForPlayer(board.sideToMove)
+	var moves []weightedMove
+	for _, entry := range b.entries[first:] {
+		if entry.key != key {
+			break
+		}
+		// A hash collision or a broken book can give moves that aren't legal here
+		if move, ok := matchPolyglotMove(legalMoves, entry.move); ok {
+			moves = append(moves, weightedMove{move, int(entry.weight)})
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].weight > moves[j].weight })
+	return moves
+}
+
+// Picks one of the book's moves in the board's position.  Moves with no weight are never picked.
+func (b *OpeningBook) chooseBoardMove(board *chessBoard, selection BookSelection) (chessMove, bool) {
+	moves := b.boardMoves(board)
+	total := 0
+	for _, move := range moves {
+		total += move.weight
+	}
+	if total == 0 {
+		return nilMove, false
+	}
+	if selection == BestBookMove {
+		return moves[0].move, true
+	}
+
+	pick := rand.Intn(total)
+	for _, move := range moves {
+		if pick < move.weight {
+			return move.move, true
+		}
+		pick -= move.weight
+	}
+	panic("weighted book move out of range")
+}
+
+// Finds the legal move written as a Polyglot move: bits 0-5 give the target square, bits 6-11 the starting
+// square and bits 12-14 the promotion piece.  Castling is written as the king taking its own rook, as it is on
+// the board.
+func matchPolyglotMove(legalMoves []chessMove, polyglotMove uint16) (chessMove, bool) {
+	to := indexToSquare(int(polyglotMove & 63))
+	from := indexToSquare(int(polyglotMove >> 6 & 63))
+	promotion := polyglotPromotions[polyglotMove>>12&7]
+	for _, move := range legalMoves {
+		if move.from == from && move.to == to && move.promotion == promotion {
+			return move, true
+		}
+	}
+	return nilMove, false
+}
+
+// The position's key in Polyglot books.  Like the board's own hash it is a Zobrist hash, but built from the
+// fixed keys of the Polyglot format.  The en passant file counts whenever a pawn of the side to move stands
+// beside the pawn that has just moved two squares, whether or not the capture is legal.
+func (cb *chessBoard) polyglotKey() uint64 {
+	var key uint64
+	for _, color := range []pieceColor{white, black} {
+		colorOffset := 1
+		if color == black {
+			colorOffset = 0
+		}
+		for pieceType := pawn; pieceType <= king; pieceType++ {
+			kind := 2*polyglotPieceKinds[pieceType] + colorOffset
+			for pieces := cb.pieceBitboards[color][pieceType]; pieces != 0; {
+				key ^= polyglotRandom[64*kind+pieces.popFirst()]
+			}
+		}
+	}
+
+	rights := cb.castlingRights()
+	for i := range 4 {
+		if rights&(1<<i) != 0 {
+			key ^= polyglotRandom[polyglotCastlingOffset+i]
+		}
+	}
+
+	if cb.enpassantSquare != nilSquare {
+		opponent := cb.sideToMove.oppositeColor()
+		if pawnAttacks[opponent][squareIndex(cb.enpassantSquare)]&cb.pieceBitboards[cb.sideToMove][pawn] != 0 {
+			key ^= polyglotRandom[polyglotEnPassantOffset+cb.enpassantSquare.x]
+		}
+	}
+
+	if cb.sideToMove == white {
+		key ^= polyglotRandom[polyglotTurnOffset]
+	}
+	return key
+}
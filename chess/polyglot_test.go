@@ -0,0 +1,143 @@
+package chess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Keys from the Polyglot book format specification
+func TestPolyglotKey(t *testing.T) {
+	tests := []struct {
+		moves []string
+		key   uint64
+	}{
+		{nil, 0x463b96181691fc9c},
+		{[]string{"e2e4"}, 0x823c9b50fd114196},
+		{[]string{"e2e4", "d7d5"}, 0x0756b94461c50fb0},
+		{[]string{"e2e4", "d7d5", "e4e5"}, 0x662fafb965db29d4},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, 0x652a607ca3f242c1},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, 0x00fdd303c946bdd9},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, 0x3c8123ea7b067637},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, 0x5c3f9b829b279560},
+	}
+	for _, test := range tests {
+		game := NewGame()
+		playMoves(t, game, test.moves...)
+		if key := game.board.polyglotKey(); key != test.key {
+			t.Errorf("%v has key %016x, want %016x", test.moves, key, test.key)
+		}
+	}
+}
+
+// An entry of a book written by writeTestBook
+type testBookEntry struct {
+	fen    string
+	move   string // In long algebraic notation
+	weight uint16
+}
+
+// Writes a Polyglot book of entries
+func writeTestBook(t *testing.T, entries ...testBookEntry) *bytes.Buffer {
+	t.Helper()
+	var book bytes.Buffer
+	for _, entry := range entries {
+		var board chessBoard
+		if err := board.loadFEN(entry.fen); err != nil {
+			t.Fatal(err)
+		}
+		from, _ := algebraicToSquare(entry.move[0:2])
+		to, _ := algebraicToSquare(entry.move[2:4])
+		move := uint16(squareIndex(from)<<6 | squareIndex(to))
+		if len(entry.move) == 5 {
+			promotion, _ := pieceFromSANLetter(entry.move[4] - 'a' + 'A')
+			for number, polyglotPromotion := range polyglotPromotions {
+				if polyglotPromotion == promotion && promotion != empty {
+					move |= uint16(number) << 12
+				}
+			}
+		}
+		binary.Write(&book, binary.BigEndian, board.polyglotKey())
+		binary.Write(&book, binary.BigEndian, move)
+		binary.Write(&book, binary.BigEndian, entry.weight)
+		binary.Write(&book, binary.BigEndian, uint32(0))
+	}
+	return &book
+}
+
+func TestOpeningBook(t *testing.T) {
+	castlingFEN := "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+	promotionFEN := "8/4P1k1/8/8/8/8/8/4K3 w - - 0 1"
+	data := writeTestBook(t, []testBookEntry{
+		{StartingFEN, "d2d4", 1},
+		{StartingFEN, "e2e4", 3},
+		{StartingFEN, "g1f3", 0},
+		// Not legal, as if from a hash collision
+		{StartingFEN, "e2e5", 10},
+		// Polyglot writes castling as the king taking its rook
+		{castlingFEN, "e1h1", 1},
+		{promotionFEN, "e7e8n", 1},
+	}...)
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	book, err := LoadOpeningBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := NewGame()
+	moves := book.Moves(game)
+	if len(moves) != 3 || moves[0].Move.String() != "e2e4" || moves[0].Weight != 3 || moves[2].Move.String() != "g1f3" {
+		t.Fatalf("book moves in the starting position are %v, want e2e4, d2d4 and g1f3", moves)
+	}
+	if move, ok := book.ChooseMove(game, BestBookMove); !ok || move.String() != "e2e4" {
+		t.Errorf("best book move = %v, %v, want e2e4", move, ok)
+	}
+	// The move without weight is never picked
+	picked := map[string]int{}
+	for range 200 {
+		move, _ := book.ChooseMove(game, WeightedBookMove)
+		picked[move.String()]++
+	}
+	if len(picked) != 2 || picked["e2e4"] <= picked["d2d4"] {
+		t.Errorf("weighted picks were %v", picked)
+	}
+
+	for _, test := range []struct{ fen, want string }{{castlingFEN, "e1g1"}, {promotionFEN, "e7e8n"}} {
+		game, _ := NewGameFromFEN(test.fen)
+		if move, ok := book.ChooseMove(game, BestBookMove); !ok || move.String() != test.want {
+			t.Errorf("%s: book move = %v, %v, want %s", test.fen, move, ok, test.want)
+		}
+	}
+
+	playMoves(t, game, "e4")
+	if move, ok := book.ChooseMove(game, WeightedBookMove); ok {
+		t.Errorf("out of book, but %v was chosen", move)
+	}
+
+	if _, err := ReadOpeningBook(bytes.NewReader(data.Bytes()[:20])); err == nil {
+		t.Error("read a book with a partial entry")
+	}
+}
+
+func TestEnginePlaysFromBook(t *testing.T) {
+	data := writeTestBook(t, testBookEntry{StartingFEN, "b1a3", 1})
+	book, err := ReadOpeningBook(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewEngine()
+	engine.SetOpeningBook(book, BestBookMove)
+	moves := make(chan Move, 1)
+	engine.Start(NewGame(), SearchLimits{Depth: 1}, nil, func(move Move) { moves <- move })
+	if move := <-moves; move.String() != "b1a3" {
+		t.Errorf("engine played %v, want the book's b1a3", move)
+	}
+}
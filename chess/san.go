@@ -0,0 +1,171 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var sanPieceLetters = map[piece]string{
+	knight: "N",
+	bishop: "B",
+	rook:   "R",
+	queen:  "Q",
+	king:   "K",
+}
+
+func pieceFromSANLetter(letter byte) (piece, bool) {
+	for pieceType, pieceLetter := range sanPieceLetters {
+		if pieceLetter[0] == letter {
+			return pieceType, true
+		}
+	}
+	return empty, false
+}
+
+// piece letter, from file, from rank, capture, target square, promotion piece.
+// An explicit P for pawns, a missing "=" and lowercase promotion letters are tolerated because other tools emit them.
+var sanPattern = regexp.MustCompile(`^([PNBRQK])?([a-h])?([1-8])?(x)?([a-h][1-8])(?:=?([NBRQnbrq]))?$`)
+
+// Trailing en passant annotations, e.g. "exd6 e.p."
+var enPassantSuffixPattern = regexp.MustCompile(`\s*e\.?p\.?$`)
+
+// Returns the Standard Algebraic Notation of a legal move for the side to move
+func (cb *chessBoard) moveToSAN(move chessMove) string {
+	movedPiece := move.movedPiece
+
+	var san string
+	if move.is(castlingFlag) {
+		san = "O-O"
+		if move.to.x < move.from.x {
+			san = "O-O-O"
+		}
+	} else if movedPiece.pieceType == pawn {
+		if move.is(captureFlag) {
+			san = squareToAlgebraic(move.from)[:1] + "x"
+		}
+		san += squareToAlgebraic(move.to)
+		if move.is(promotionFlag) {
+			san += "=" + sanPieceLetters[move.promotion]
+		}
+	} else {
+		san = sanPieceLetters[movedPiece.pieceType] + cb.sanDisambiguation(move)
+		if move.is(captureFlag) {
+			san += "x"
+		}
+		san += squareToAlgebraic(move.to)
+	}
+
+	// Check and checkmate suffixes
+	boardCopy := *cb
+	boardCopy.movePiece(move)
+	opponent := movedPiece.color.oppositeColor()
+	if boardCopy.playerInCheckMate(opponent) {
+		san += "#"
+	} else if boardCopy.playerInCheck(opponent) {
+		san += "+"
+	}
+
+	return san
+}
+
+// Returns the file, rank or square needed to tell this move apart from other pieces of the same type that can reach its target square
+func (cb *chessBoard) sanDisambiguation(move chessMove) string {
+	var rivals []vector2
+	for _, otherMove := range cb.getAllValidMovesForPlayer(move.movedPiece.color) {
+		if otherMove.to == move.to && otherMove.from != move.from && otherMove.movedPiece == move.movedPiece {
+			rivals = append(rivals, otherMove.from)
+		}
+	}
+	if len(rivals) == 0 {
+		return ""
+	}
+
+	sharesFile, sharesRank := false, false
+	for _, rival := range rivals {
+		sharesFile = sharesFile || rival.x == move.from.x
+		sharesRank = sharesRank || rival.y == move.from.y
+	}
+
+	squareName := squareToAlgebraic(move.from)
+	if !sharesFile {
+		return squareName[:1]
+	}
+	if !sharesRank {
+		return squareName[1:]
+	}
+	return squareName
+}
+
+// Resolves a SAN move for the side to move into a legal move
+func (cb *chessBoard) sanToMove(san string) (chessMove, error) {
+	// Strip check, checkmate, en passant and annotation suffixes
+	cleanSAN := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+	cleanSAN = enPassantSuffixPattern.ReplaceAllString(cleanSAN, "")
+
+	color := cb.sideToMove
+
+	castling, kingSide := false, false
+	switch cleanSAN {
+	case "O-O", "0-0":
+		castling, kingSide = true, true
+	case "O-O-O", "0-0-0":
+		castling = true
+	}
+	if castling {
+		for _, move := range cb.getAllValidMovesForPlayer(color) {
+			if move.is(castlingFlag) && (move.to.x > move.from.x) == kingSide {
+				return move, nil
+			}
+		}
+		return nilMove, fmt.Errorf("%q is not a legal move for %s", san, color.name())
+	}
+
+	match := sanPattern.FindStringSubmatch(cleanSAN)
+	if match == nil {
+		return nilMove, fmt.Errorf("%q is not a valid SAN move", san)
+	}
+
+	pieceType := pawn
+	if match[1] != "" && match[1] != "P" {
+		pieceType, _ = pieceFromSANLetter(match[1][0])
+	}
+	fromFile, fromRank := -1, -1
+	if match[2] != "" {
+		fromFile = int(match[2][0] - 'a')
+	}
+	if match[3] != "" {
+		fromRank = int(match[3][0] - '1')
+	}
+	targetSquare, _ := algebraicToSquare(match[5])
+	promotion := empty
+	if match[6] != "" {
+		promotion, _ = pieceFromSANLetter(strings.ToUpper(match[6])[0])
+	}
+
+	var candidates []chessMove
+	for _, move := range cb.getAllValidMovesForPlayer(color) {
+		if move.to != targetSquare || move.movedPiece.pieceType != pieceType || move.is(castlingFlag) {
+			continue
+		}
+		if (fromFile != -1 && move.from.x != fromFile) || (fromRank != -1 && move.from.y != fromRank) {
+			continue
+		}
+		if move.promotion != promotion {
+			continue
+		}
+		candidates = append(candidates, move)
+	}
+
+	switch len(candidates) {
+	case 0:
+		if pieceType == pawn && promotion == empty && (targetSquare.y == 0 || targetSquare.y == 7) {
+			return nilMove, fmt.Errorf("%q is missing its promotion piece", san)
+		}
+		return nilMove, fmt.Errorf("%q is not a legal move for %s", san, color.name())
+	case 1:
+		return candidates[0], nil
+	default:
+		return nilMove, fmt.Errorf("%q is ambiguous", san)
+	}
+}
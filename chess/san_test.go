@@ -0,0 +1,102 @@
+package chess
+
+import "testing"
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		fen  string
+		san  string // As written, in any form sanToMove accepts
+		move string // The move it names, in long algebraic notation
+		want string // The move written back in SAN
+	}{
+		{StartingFEN, "e4", "e2e4", "e4"},
+		{StartingFEN, "Pe4", "e2e4", "e4"},
+		{StartingFEN, "Pe2e4", "e2e4", "e4"},
+		{StartingFEN, "Nf3!?", "g1f3", "Nf3"},
+
+		// Promotions, with the letter in any case and with or without "="
+		{"8/P3k3/8/8/8/8/8/4K3 w - - 0 1", "a8=Q", "a7a8q", "a8=Q"},
+		{"8/P3k3/8/8/8/8/8/4K3 w - - 0 1", "a8q", "a7a8q", "a8=Q"},
+		{"8/P3k3/8/8/8/8/8/4K3 w - - 0 1", "a8=n", "a7a8n", "a8=N"},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=R+", "a7b8r", "axb8=R+"},
+
+		// En passant, with or without the annotation
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", "e5d6", "exd6"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6 e.p.", "e5d6", "exd6"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6ep", "e5d6", "exd6"},
+
+		// Disambiguation by file, then rank, then both
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "Rad1", "a1d1", "Rad1"},
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "Rfd1", "f1d1", "Rfd1"},
+		{"7k/8/8/R7/8/8/8/R6K w - - 0 1", "R1a3", "a1a3", "R1a3"},
+		{"7k/8/8/R7/8/8/8/R6K w - - 0 1", "R5a3", "a5a3", "R5a3"},
+		{"8/8/8/7k/8/Q7/8/Q1Q4K w - - 0 1", "Qa1b2", "a1b2", "Qa1b2"},
+		{"8/8/8/7k/8/Q7/8/Q1Q4K w - - 0 1", "Qcb2", "c1b2", "Qcb2"},
+		{"8/8/8/7k/8/Q7/8/Q1Q4K w - - 0 1", "Q3b2", "a3b2", "Q3b2"},
+		{"k7/8/8/8/8/5N2/8/1N5K w - - 0 1", "Nbd2", "b1d2", "Nbd2"},
+
+		// Check and checkmate suffixes, which are optional when reading
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "Ra8", "a1a8", "Ra8+"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8+", "a1a8", "Ra8#"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8#", "a1a8", "Ra8#"},
+		{"5k2/8/8/8/8/8/8/4K2R w K - 0 1", "O-O", "e1g1", "O-O+"},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "0-0-0", "e8c8", "O-O-O"},
+	}
+	for _, test := range tests {
+		var board chessBoard
+		if err := board.loadFEN(test.fen); err != nil {
+			t.Fatal(err)
+		}
+		move, err := board.sanToMove(test.san)
+		if err != nil {
+			t.Errorf("%s: %v", test.fen, err)
+			continue
+		}
+		if got := board.moveOf(move).String(); got != test.move {
+			t.Errorf("%s: %q is %s, want %s", test.fen, test.san, got, test.move)
+		}
+		if got := board.moveToSAN(move); got != test.want {
+			t.Errorf("%s: %s written as %q, want %q", test.fen, test.move, got, test.want)
+		}
+	}
+}
+
+func TestSANRejected(t *testing.T) {
+	tests := []struct {
+		fen string
+		san string
+	}{
+		{StartingFEN, "e5"},
+		{StartingFEN, "Nc4"},
+		{StartingFEN, "O-O"},
+		{StartingFEN, "e2-e4"},
+		{StartingFEN, "Xe4"},
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "Rd1"},
+		{"8/P3k3/8/8/8/8/8/4K3 w - - 0 1", "a8"},
+		{"8/P3k3/8/8/8/8/8/4K3 w - - 0 1", "a8=K"},
+	}
+	for _, test := range tests {
+		var board chessBoard
+		if err := board.loadFEN(test.fen); err != nil {
+			t.Fatal(err)
+		}
+		if move, err := board.sanToMove(test.san); err == nil {
+			t.Errorf("%s: %q resolved to %s", test.fen, test.san, board.moveOf(move))
+		}
+	}
+}
+
+func TestEnPassantRemovesCapturedPawn(t *testing.T) {
+	var board chessBoard
+	if err := board.loadFEN("4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	move, err := board.sanToMove("exd6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	board.movePiece(move)
+	if want := "4k3/8/3P4/8/8/8/8/4K3 b - - 0 1"; board.toFEN() != want {
+		t.Errorf("en passant left %q, want %q", board.toFEN(), want)
+	}
+}
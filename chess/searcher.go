@@ -0,0 +1,137 @@
+package chess
+
+import (
+	"io"
+	"time"
+)
+
+// A chess engine that can search a position in the background: the built-in engine or an external UCI one
+type moveSearcher interface {
+	// Starts searching board, which the searcher doesn't modify, and returns straight away.  record, if not
+	// nil, is the game that led to board, for searchers that are given the moves rather than the position.
+	// done is called from another goroutine with the best move.
+	start(board *chessBoard, record *gameRecord, limits searchLimits, report func(searchInfo), done func(chessMove))
+	// Makes a running search finish as soon as possible
+	stop()
+}
+
+// Limits on a search.  The search stops at whichever is reached first, or when stopped if neither is set.
+type SearchLimits struct {
+	Depth    int           // Maximum depth in plies, 0 for no limit
+	MoveTime time.Duration // Time to think, 0 for no limit
+}
+
+// Progress of a search, reported each time the engine completes a depth
+type SearchInfo struct {
+	Depth   int
+	Score   int // Centipawns for the side to move
+	MateIn  int // Moves until mate if the engine has found one, negative when the side to move is mated
+	Nodes   int
+	Elapsed time.Duration
+	PV      []Move // Principal variation, the line the engine expects
+}
+
+// A chess engine that searches games in the background, either the built-in engine or an external UCI engine
+// such as Stockfish.  An engine runs one search at a time.
+type Engine struct {
+	name          string
+	searcher      moveSearcher
+	book          *OpeningBook // Played from before searching, if set
+	bookSelection BookSelection
+	tablebase     *Tablebase // Played from instead of searching, if set
+}
+
+// The built-in engine
+func NewEngine() *Engine {
+	return &Engine{name: uciEngineName, searcher: newEngine()}
+}
+
+// The built-in engine with the evaluation weights in a JSON file.  Weights missing from the file keep their
+// default values.
+func NewEngineWithWeights(path string) (*Engine, error) {
+	evaluator, err := loadEvaluator(path)
+	if err != nil {
+		return nil, err
+	}
+	builtIn := newEngine()
+	builtIn.evaluator = evaluator
+	return &Engine{name: uciEngineName, searcher: builtIn}, nil
+}
+
+// Starts the UCI engine program at path and waits for it to be ready.  Close quits it.
+func StartUCIEngine(path string, args ...string) (*Engine, error) {
+	external, err := startUCIEngine(path, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{name: external.name, searcher: external}, nil
+}
+
+// The engine's name, as given by UCI engines
+func (e *Engine) Name() string {
+	return e.name
+}
+
+// Has the engine play from book while the book has a move, choosing among its moves by selection.  A nil book
+// turns the book off.
+func (e *Engine) SetOpeningBook(book *OpeningBook, selection BookSelection) {
+	e.book, e.bookSelection = book, selection
+}
+
+// Has the engine play perfectly once the position is in the tablebases.  The built-in engine also probes them
+// during its search, to steer into won endgames and away from lost ones.  A nil tablebase turns them off.
+func (e *Engine) SetTablebase(tablebase *Tablebase) {
+	e.tablebase = tablebase
+	if builtIn, ok := e.searcher.(*engine); ok {
+		builtIn.tablebase = tablebase
+	}
+}
+
+// Starts searching the game's current position and returns straight away.  report, if not nil, is called
+// with the search's progress and done with the best move, both from another goroutine.  done receives the
+// zero Move if the engine has no move to give.  A book or tablebase move is given without searching, except to
+// a search without limits, which is taken to be analysis.
+func (e *Engine) Start(game *Game, limits SearchLimits, report func(SearchInfo), done func(Move)) {
+	if e.book != nil && limits != (SearchLimits{}) {
+		if move, ok := e.book.ChooseMove(game, e.bookSelection); ok {
+			go done(move)
+			return
+		}
+	}
+	if e.tablebase != nil && limits != (SearchLimits{}) {
+		if move, err := e.tablebase.BestMove(game); err == nil {
+			go done(move)
+			return
+		}
+	}
+
+	var reportInfo func(searchInfo)
+	if report != nil {
+		reportInfo = func(info searchInfo) {
+			report(SearchInfo{
+				Depth:   info.depth,
+				Score:   info.score,
+				MateIn:  info.mateIn(),
+				Nodes:   info.nodes,
+				Elapsed: info.elapsed,
+				PV:      game.board.movesOf(info.pv),
+			})
+		}
+	}
+	e.searcher.start(&game.board, &game.record, searchLimits{depth: limits.Depth, moveTime: limits.MoveTime}, reportInfo, func(move chessMove) {
+		done(game.board.moveOf(move))
+	})
+}
+
+// Makes a running search finish as soon as possible
+func (e *Engine) Stop() {
+	e.searcher.stop()
+}
+
+// Quits an external engine.  Does nothing for the built-in one.
+func (e *Engine) Close() error {
+	if closer, ok := e.searcher.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
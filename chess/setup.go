@@ -0,0 +1,119 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Castling rights of a position being set up.  A side castles with its outermost rook on that side of its king.
+type CastlingRights struct {
+	WhiteKingside  bool
+	WhiteQueenside bool
+	BlackKingside  bool
+	BlackQueenside bool
+}
+
+// A position being set up square by square, such as a puzzle or a test position.  It can be anything while it
+// is being set up, and is only checked once a game is started from it.
+type Setup struct {
+	Pieces    [64]Piece // Indexed by Square
+	Turn      Color
+	Castling  CastlingRights
+	EnPassant Square // The square skipped by a pawn that has just moved two squares, NoSquare for none
+}
+
+// A setup of the game's current position.  Chess960 castling rights with an inner rook are given to the
+// outermost rook on that side.
+func (g *Game) Setup() Setup {
+	setup := Setup{Turn: g.Turn(), EnPassant: squareOf(g.board.enpassantSquare)}
+	for square := range setup.Pieces {
+		setup.Pieces[square] = g.Piece(Square(square))
+	}
+	rights := g.board.castlingRights()
+	setup.Castling = CastlingRights{
+		WhiteKingside:  rights&whiteKingSideCastling != 0,
+		WhiteQueenside: rights&whiteQueenSideCastling != 0,
+		BlackKingside:  rights&blackKingSideCastling != 0,
+		BlackQueenside: rights&blackQueenSideCastling != 0,
+	}
+	return setup
+}
+
+// Starts a game from a position that has been set up.  Returns an error if no game can be played from it, such
+// as when a side doesn't have exactly one king, a pawn stands on the first or eighth rank, or the side that
+// isn't to move is in check, or a piece has no color or isn't a type of piece.
+func NewGameFromSetup(setup Setup) (*Game, error) {
+	for square, piece := range setup.Pieces {
+		if piece != (Piece{}) && !piece.valid() {
+			return nil, fmt.Errorf("invalid setup: %v holds a piece of type %d and color %d", Square(square), piece.Type, piece.Color)
+		}
+	}
+	return NewGameFromFEN(setup.FEN())
+}
+
+// Whether the piece is one of the twelve that can stand on a square
+func (p Piece) valid() bool {
+	return p.Type >= Pawn && p.Type <= King && (p.Color == White || p.Color == Black)
+}
+
+// The piece on a square, the zero Piece if it is empty or off the board
+func (s *Setup) Piece(square Square) Piece {
+	if !square.onBoard() {
+		return Piece{}
+	}
+	return s.Pieces[square]
+}
+
+// The position in Forsyth-Edwards Notation, from its first move.  Pieces that aren't valid are left out.
+func (s *Setup) FEN() string {
+	var board chessBoard
+	for square, setupPiece := range s.Pieces {
+		if !setupPiece.valid() {
+			continue
+		}
+		board.setPiece(Square(square).vector(), chessPiece{piece(setupPiece.Type), pieceColor(setupPiece.Color)})
+	}
+
+	turn := "w"
+	if s.Turn == Black {
+		turn = "b"
+	}
+
+	var castling strings.Builder
+	for _, right := range []struct {
+		allowed bool
+		fen     byte
+	}{
+		{s.Castling.WhiteKingside, 'K'},
+		{s.Castling.WhiteQueenside, 'Q'},
+		{s.Castling.BlackKingside, 'k'},
+		{s.Castling.BlackQueenside, 'q'},
+	} {
+		if right.allowed {
+			castling.WriteByte(right.fen)
+		}
+	}
+	if castling.Len() == 0 {
+		castling.WriteByte('-')
+	}
+
+	return fmt.Sprintf("%s %s %s %s 0 1", board.placementFEN(), turn, castling.String(), s.EnPassant)
+}
+
+// The squares that a pawn of the side not to move could just have skipped by moving two squares, which are
+// the squares EnPassant can be set to
+func (s *Setup) EnPassantSquares() []Square {
+	moved, pawnRank, direction := White, 3, -1
+	if s.Turn != Black {
+		moved, pawnRank, direction = Black, 4, 1
+	}
+
+	var squares []Square
+	for file := range 8 {
+		skipped, start := SquareAt(file, pawnRank+direction), SquareAt(file, pawnRank+2*direction)
+		if s.Pieces[SquareAt(file, pawnRank)] == (Piece{Pawn, moved}) && s.Pieces[skipped] == (Piece{}) && s.Pieces[start] == (Piece{}) {
+			squares = append(squares, skipped)
+		}
+	}
+	return squares
+}
@@ -0,0 +1,68 @@
+package chess
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	game := NewGame()
+	playMoves(t, game, "e4", "Nf6", "Ke2", "Ng8", "e5", "d5")
+	setup := game.Setup()
+	if setup.EnPassant != SquareAt(3, 5) || setup.Castling != (CastlingRights{BlackKingside: true, BlackQueenside: true}) {
+		t.Errorf("setup of %q has en passant %v and castling %+v", game.FEN(), setup.EnPassant, setup.Castling)
+	}
+	if want := "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPPKPPP/RNBQ1BNR w kq d6 0 1"; setup.FEN() != want {
+		t.Errorf("setup FEN is %q, want %q", setup.FEN(), want)
+	}
+
+	// A position set up piece by piece
+	setup = Setup{Turn: White, EnPassant: NoSquare}
+	setup.Pieces[SquareAt(4, 0)] = Piece{King, White}
+	setup.Pieces[SquareAt(7, 0)] = Piece{Rook, White}
+	setup.Pieces[SquareAt(4, 7)] = Piece{King, Black}
+	setup.Castling.WhiteKingside = true
+	game, err := NewGameFromSetup(setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "4k3/8/8/8/8/8/8/4K2R w K - 0 1"; game.FEN() != want || game.StartFEN() != want {
+		t.Errorf("set up %q, want %q", game.FEN(), want)
+	}
+	playMoves(t, game, "O-O")
+
+	for name, change := range map[string]func(*Setup){
+		"a second white king":   func(s *Setup) { s.Pieces[SquareAt(0, 3)] = Piece{King, White} },
+		"no black king":         func(s *Setup) { s.Pieces[SquareAt(4, 7)] = Piece{} },
+		"a pawn on rank 8":      func(s *Setup) { s.Pieces[SquareAt(0, 7)] = Piece{Pawn, White} },
+		"a pawn on rank 1":      func(s *Setup) { s.Pieces[SquareAt(0, 0)] = Piece{Pawn, Black} },
+		"black in check":        func(s *Setup) { s.Pieces[SquareAt(4, 3)] = Piece{Rook, White} },
+		"castling without rook": func(s *Setup) { s.Castling.WhiteQueenside = true },
+		"no double pawn push":   func(s *Setup) { s.EnPassant = SquareAt(3, 5) },
+		"a piece with no color": func(s *Setup) { s.Pieces[SquareAt(0, 3)] = Piece{Type: Queen} },
+		"no type of piece":      func(s *Setup) { s.Pieces[SquareAt(0, 3)] = Piece{Type: 9, Color: White} },
+	} {
+		changed := setup
+		change(&changed)
+		if _, err := NewGameFromSetup(changed); err == nil {
+			t.Errorf("started a game from a setup with %s", name)
+		}
+	}
+}
+
+func TestSetupEnPassantSquares(t *testing.T) {
+	game, err := NewGameFromFEN("4k3/7n/8/pP1Pp2p/3p2P1/2P5/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := game.Setup()
+	// Black's pawns on a5 and e5 could have come from the 7th rank, but the knight on h7 blocks the pawn on h5
+	if squares := setup.EnPassantSquares(); !slices.Equal(squares, []Square{SquareAt(0, 5), SquareAt(4, 5)}) {
+		t.Errorf("white to move has en passant squares %v, want a6 and e6", squares)
+	}
+	// White's pawn on g4 could have come from g2, but the pawn on d4 is black's
+	setup.Turn = Black
+	if squares := setup.EnPassantSquares(); !slices.Equal(squares, []Square{SquareAt(6, 2)}) {
+		t.Errorf("black to move has en passant squares %v, want g3", squares)
+	}
+}
@@ -0,0 +1,593 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Win, draw or loss for the side to move with perfect play.  Cursed wins and blessed losses are wins and
+// losses that the fifty-move rule turns into draws.
+type WDL int
+
+const (
+	TablebaseLoss        WDL = -2
+	TablebaseBlessedLoss WDL = -1
+	TablebaseDraw        WDL = 0
+	TablebaseCursedWin   WDL = 1
+	TablebaseWin         WDL = 2
+)
+
+func (wdl WDL) String() string {
+	switch wdl {
+	case TablebaseLoss:
+		return "loss"
+	case TablebaseBlessedLoss:
+		return "blessed loss"
+	case TablebaseCursedWin:
+		return "cursed win"
+	case TablebaseWin:
+		return "win"
+	}
+	return "draw"
+}
+
+// The result of a position in the tablebases for the side to move, ignoring the fifty-move count so far
+type TablebaseResult struct {
+	WDL WDL
+	// Distance to zeroing: plies until the next capture, pawn move or checkmate with perfect play.  Positive
+	// when the side to move wins, negative when it loses, and 0 for a draw.
+	DTZ int
+}
+
+// Describes the result, e.g. "win in 7", counting the moves of the side to move until the fifty-move count is
+// reset by a capture, pawn move or checkmate
+func (r TablebaseResult) String() string {
+	switch {
+	case r.DTZ > 0:
+		return fmt.Sprintf("%s in %d", r.WDL, (r.DTZ+1)/2)
+	case r.DTZ < -1:
+		return fmt.Sprintf("%s in %d", r.WDL, -r.DTZ/2)
+	}
+	return r.WDL.String()
+}
+
+// Syzygy endgame tablebases, read from .rtbw (win/draw/loss) and .rtbz (distance to zeroing) files.  Tables are
+// read when first probed, and may be probed from several goroutines at once.
+type Tablebase struct {
+	tables    map[string]*syzygyTable // By material with the stronger side first, such as "KRvK"
+	maxPieces int
+}
+
+// Opens the Syzygy tables in a directory, or in several separated by the system's path list separator as in
+// the SyzygyPath UCI option
+func OpenTablebase(paths string) (*Tablebase, error) {
+	tb := &Tablebase{tables: map[string]*syzygyTable{}}
+	for _, dir := range filepath.SplitList(paths) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			extension := filepath.Ext(entry.Name())
+			if extension != ".rtbw" && extension != ".rtbz" {
+				continue
+			}
+			material := strings.TrimSuffix(entry.Name(), extension)
+			table, ok := tb.tables[material]
+			if !ok {
+				if table, ok = newSyzygyTable(material); !ok {
+					continue
+				}
+				table.dtz.dtz = true
+				tb.tables[material] = table
+			}
+			if extension == ".rtbw" {
+				table.wdl.path = filepath.Join(dir, entry.Name())
+				tb.maxPieces = max(tb.maxPieces, table.pieceCount)
+			} else {
+				table.dtz.path = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	if tb.maxPieces == 0 {
+		return nil, fmt.Errorf("no Syzygy tables in %s", paths)
+	}
+	return tb, nil
+}
+
+// The most pieces, kings included, of the positions in the tablebases
+func (tb *Tablebase) MaxPieces() int {
+	return tb.maxPieces
+}
+
+// Looks up the game's current position.  Fails for positions the tablebases don't have, such as ones with
+// more pieces or with castling rights.
+func (tb *Tablebase) Probe(game *Game) (TablebaseResult, error) {
+	if err := tb.checkCovered(&game.board); err != nil {
+		return TablebaseResult{}, err
+	}
+	wdl, err := tb.probeWDL(&game.board)
+	if err != nil {
+		return TablebaseResult{}, err
+	}
+	dtz, err := tb.probeDTZ(&game.board)
+	if err != nil {
+		return TablebaseResult{}, err
+	}
+	// Cursed wins and blessed losses are told apart by their DTZ being offset by 100
+	if wdl == TablebaseCursedWin {
+		dtz -= 100
+	} else if wdl == TablebaseBlessedLoss {
+		dtz += 100
+	}
+	return TablebaseResult{wdl, dtz}, nil
+}
+
+// The move that keeps the best result in the game's current position: when winning, the one that resets the
+// fifty-move count soonest, and when losing, the one that puts it off longest
+func (tb *Tablebase) BestMove(game *Game) (Move, error) {
+	if game.outcome.IsOver() {
+		return Move{}, errors.New("the game is over")
+	}
+	moves, err := tb.rootMoves(&game.board)
+	if err != nil {
+		return Move{}, err
+	}
+	return game.board.moveOf(moves[0]), nil
+}
+
+// Fails if the board's position can't be in the tablebases
+func (tb *Tablebase) checkCovered(cb *chessBoard) error {
+	if pieces := cb.occupied().count(); pieces > tb.maxPieces {
+		return fmt.Errorf("the position has %d pieces, the tablebases have up to %d", pieces, tb.maxPieces)
+	}
+	if cb.castlingRooks != 0 {
+		return errors.New("the tablebases don't have positions with castling rights")
+	}
+	return nil
+}
+
+// What probing a single table found out beyond its value
+type syzygyProbeState int
+
+const (
+	syzygyProbeOK syzygyProbeState = iota
+	// The best move resets the fifty-move count, so the DTZ table's value can't be relied on
+	syzygyZeroingBestMove
+	// The DTZ table only stores the other side to move
+	syzygyChangeSideToMove
+)
+
+// Looks up the board's position in its WDL table, or in its DTZ table with the position's WDL known.  Neither
+// table accounts for en passant, which search does.
+func (tb *Tablebase) probeTable(cb *chessBoard, dtz bool, wdl WDL) (int, syzygyProbeState, error) {
+	if cb.occupied().count() == 2 {
+		return 0, syzygyProbeOK, nil // Bare kings are a draw
+	}
+
+	whiteMaterial, blackMaterial := cb.syzygyMaterial(white), cb.syzygyMaterial(black)
+	table, blackStronger := tb.tables[whiteMaterial+"v"+blackMaterial], false
+	if table == nil {
+		table, blackStronger = tb.tables[blackMaterial+"v"+whiteMaterial], true
+	}
+	if table == nil {
+		return 0, syzygyProbeOK, fmt.Errorf("no table for %sv%s", whiteMaterial, blackMaterial)
+	}
+	file := &table.wdl
+	if dtz {
+		file = &table.dtz
+	}
+	if err := file.load(table); err != nil {
+		return 0, syzygyProbeOK, err
+	}
+
+	d, pawnFile, sideToMove, idx := file.locate(table, cb, blackStronger)
+	if dtz {
+		// DTZ tables store one side to move, except for tables with the same pieces on each side and no pawns,
+		// where turning the board over swaps the sides
+		stored := int(d.flags & syzygySideToMoveFlag)
+		if stored != sideToMove && !(table.symmetric && !table.hasPawns) {
+			return 0, syzygyChangeSideToMove, nil
+		}
+	}
+
+	value, err := d.value(idx)
+	if err != nil {
+		return 0, syzygyProbeOK, err
+	}
+	if !dtz {
+		return value - 2, syzygyProbeOK, nil
+	}
+	return file.dtzValue(pawnFile, value, wdl), syzygyProbeOK, nil
+}
+
+// Finds the board's position in the file: the compressed values it is stored in, the file of its leading pawn,
+// its side to move once the stronger side is white, and its index
+func (f *syzygyFile) locate(table *syzygyTable, cb *chessBoard, blackStronger bool) (*syzygyPairs, int, int, uint64) {
+	// Tables are stored with the stronger side as white, and tables with the same pieces on both sides with
+	// white to move, so other positions are looked up with the colors swapped and the board turned over
+	flip := blackStronger || (table.symmetric && cb.sideToMove == black)
+	flipColor, flipSquares := 0, 0
+	if flip {
+		flipColor, flipSquares = syzygyBlackCode, 56
+	}
+	sideToMove := 0
+	if flip != (cb.sideToMove == black) {
+		sideToMove = 1
+	}
+
+	var squares, pieces [maxSyzygyPieces]int
+	size := 0
+	leadPawnCount := 0
+	var leadPawns bitboard
+	pawnFile := 0
+	if table.hasPawns {
+		// Tables with pawns are split by the file of the leading pawn: of the side's pawns that the table
+		// lists first, the one nearest the edge, and the lowest of those
+		leadColor := white
+		if (f.pairs[0][0].pieces[0]^flipColor)&syzygyBlackCode != 0 {
+			leadColor = black
+		}
+		leadPawns = cb.pieceBitboards[leadColor][pawn]
+		for pawns := leadPawns; pawns != 0; {
+			squares[size] = pawns.popFirst() ^ flipSquares
+			size++
+		}
+		leadPawnCount = size
+		lead := 0
+		for i := 1; i < leadPawnCount; i++ {
+			if syzygyMapPawns[squares[i]] > syzygyMapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+		pawnFile = min(squares[0]%8, 7-squares[0]%8)
+	}
+
+	for others := cb.occupied() &^ leadPawns; others != 0; {
+		square := others.popFirst()
+		piece := cb.board[square%8][square/8]
+		squares[size] = square ^ flipSquares
+		pieces[size] = syzygyPieceCodes[piece.pieceType] ^ flipColor
+		if piece.color == black {
+			pieces[size] ^= syzygyBlackCode
+		}
+		size++
+	}
+
+	d := f.pairs[0][pawnFile]
+	if !f.dtz && !table.symmetric {
+		d = f.pairs[sideToMove][pawnFile]
+	}
+
+	// Put the pieces in the order the table encodes them in
+	for i := leadPawnCount; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// Mirror the board so that the first piece is on the a to d files
+	if squares[0]%8 > 3 {
+		for i := range size {
+			squares[i] ^= 7
+		}
+	}
+
+	var idx uint64
+	if table.hasPawns {
+		idx = syzygyLeadPawnIdx[leadPawnCount][squares[0]]
+		slices.SortStableFunc(squares[1:leadPawnCount], func(a, b int) int {
+			return syzygyMapPawns[a] - syzygyMapPawns[b]
+		})
+		for i := 1; i < leadPawnCount; i++ {
+			idx += syzygyBinomial[i][syzygyMapPawns[squares[i]]]
+		}
+	} else {
+		idx = d.leadingPiecesIndex(table, squares[:size])
+	}
+
+	// The other groups are each encoded as the combination of the squares left for them
+	idx *= d.groupIdx[0]
+	groupStart := d.groupLen[0]
+	remainingPawns := table.hasPawns && table.pawnCount[1] > 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[groupStart : groupStart+d.groupLen[next]]
+		slices.Sort(group)
+		n := uint64(0)
+		for i, square := range group {
+			adjust := 0
+			for _, earlier := range squares[:groupStart] {
+				if square > earlier {
+					adjust++
+				}
+			}
+			if remainingPawns {
+				adjust += 8
+			}
+			n += syzygyBinomial[i+1][square-adjust]
+		}
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		groupStart += d.groupLen[next]
+	}
+
+	return d, pawnFile, sideToMove, idx
+}
+
+// Index of the leading group of a table without pawns: the kings, or three pieces when there is a single
+// piece of a kind.  The board is first mirrored so that the first piece is in the a1-d1-d4 triangle, and
+// below the diagonal the first piece off it.
+func (d *syzygyPairs) leadingPiecesIndex(table *syzygyTable, squares []int) uint64 {
+	if squares[0]/8 > 3 {
+		for i := range squares {
+			squares[i] ^= 56
+		}
+	}
+	for i := range d.groupLen[0] {
+		if offDiagonal(squares[i]) == 0 {
+			continue
+		}
+		if offDiagonal(squares[i]) > 0 {
+			for j := i; j < len(squares); j++ {
+				squares[j] = (squares[j]>>3 | squares[j]<<3) & 63
+			}
+		}
+		break
+	}
+
+	if !table.hasUniquePieces {
+		return uint64(syzygyMapKK[syzygyMapA1D1D4[squares[0]]][squares[1]])
+	}
+
+	adjust1, adjust2 := 0, 0
+	if squares[1] > squares[0] {
+		adjust1++
+	}
+	if squares[2] > squares[0] {
+		adjust2++
+	}
+	if squares[2] > squares[1] {
+		adjust2++
+	}
+	rank0, rank1, rank2 := squares[0]/8, squares[1]/8, squares[2]/8
+	switch {
+	case offDiagonal(squares[0]) != 0:
+		return uint64((syzygyMapA1D1D4[squares[0]]*63+squares[1]-adjust1)*62 + squares[2] - adjust2)
+	case offDiagonal(squares[1]) != 0:
+		return uint64((6*63+rank0*28+syzygyMapB1H1H7[squares[1]])*62 + squares[2] - adjust2)
+	case offDiagonal(squares[2]) != 0:
+		return uint64(6*63*62 + 4*28*62 + rank0*7*28 + (rank1-adjust1)*28 + syzygyMapB1H1H7[squares[2]])
+	}
+	return uint64(6*63*62 + 4*28*62 + 4*7*28 + rank0*6*7 + (rank1-adjust1)*6 + rank2 - adjust2)
+}
+
+// Turns a value stored in a DTZ table into plies for a position whose WDL is wdl
+func (f *syzygyFile) dtzValue(pawnFile int, value int, wdl WDL) int {
+	pairs := f.pairs[0][pawnFile]
+	if pairs.flags&syzygyMappedFlag != 0 {
+		// Which of the value map's lists applies, by WDL from loss to win
+		list := pairs.valueMapIdx[[5]int{1, 3, 0, 2, 0}[wdl+2]]
+		if pairs.flags&syzygyWideFlag != 0 {
+			at := 2 * (list + value)
+			value = int(f.valueMap[at]) | int(f.valueMap[at+1])<<8
+		} else {
+			value = int(f.valueMap[list+value])
+		}
+	}
+
+	if (wdl == TablebaseWin && pairs.flags&syzygyWinPliesFlag == 0) ||
+		(wdl == TablebaseLoss && pairs.flags&syzygyLossPliesFlag == 0) ||
+		wdl == TablebaseCursedWin || wdl == TablebaseBlessedLoss {
+		value *= 2
+	}
+	return value + 1
+}
+
+// Works out the WDL of the board's position by trying captures, and with checkZeroing pawn moves, before
+// looking it up.  The tables don't store positions where en passant is possible, and store any value for
+// positions where a capture is best.
+func (tb *Tablebase) search(cb *chessBoard, checkZeroing bool) (WDL, syzygyProbeState, error) {
+	board := cb.deepCopy()
+	moves := board.getAllValidMovesForPlayer(board.sideToMove)
+	bestValue := TablebaseLoss
+	moveCount := 0
+	for _, move := range moves {
+		if !move.is(captureFlag) && (!checkZeroing || move.movedPiece.pieceType != pawn) {
+			continue
+		}
+		moveCount++
+
+		undo := board.movePiece(move)
+		value, _, err := tb.search(&board, false)
+		board.unmakeMove(undo)
+		if err != nil {
+			return TablebaseDraw, syzygyProbeOK, err
+		}
+
+		if -value > bestValue {
+			bestValue = -value
+			if bestValue >= TablebaseWin {
+				return bestValue, syzygyZeroingBestMove, nil
+			}
+		}
+	}
+
+	// With every move tried there is nothing to look up
+	noMoreMoves := moveCount > 0 && moveCount == len(moves)
+	value := bestValue
+	if !noMoreMoves {
+		stored, _, err := tb.probeTable(&board, false, TablebaseDraw)
+		if err != nil {
+			return TablebaseDraw, syzygyProbeOK, err
+		}
+		value = WDL(stored)
+	}
+
+	if bestValue >= value {
+		if bestValue > TablebaseDraw || noMoreMoves {
+			return bestValue, syzygyZeroingBestMove, nil
+		}
+		return bestValue, syzygyProbeOK, nil
+	}
+	return value, syzygyProbeOK, nil
+}
+
+func (tb *Tablebase) probeWDL(cb *chessBoard) (WDL, error) {
+	wdl, _, err := tb.search(cb, false)
+	return wdl, err
+}
+
+// The DTZ of a position one ply before a zeroing move that leaves the side to move with wdl
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case TablebaseWin:
+		return 1
+	case TablebaseCursedWin:
+		return 101
+	case TablebaseBlessedLoss:
+		return -101
+	case TablebaseLoss:
+		return -1
+	}
+	return 0
+}
+
+// The board's DTZ in plies, offset by 100 for cursed wins and blessed losses, and 0 for draws
+func (tb *Tablebase) probeDTZ(cb *chessBoard) (int, error) {
+	wdl, state, err := tb.search(cb, true)
+	if err != nil || wdl == TablebaseDraw {
+		return 0, err
+	}
+	if state == syzygyZeroingBestMove {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, state, err := tb.probeTable(cb, true, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if state != syzygyChangeSideToMove {
+		if wdl == TablebaseCursedWin || wdl == TablebaseBlessedLoss {
+			dtz += 100
+		}
+		if wdl < TablebaseDraw {
+			return -dtz, nil
+		}
+		return dtz, nil
+	}
+
+	// The table stores the other side to move, so look one ply ahead for the move that resets the
+	// fifty-move count soonest when winning, or latest when losing
+	board := cb.deepCopy()
+	best := 0
+	found := false
+	for _, move := range board.getAllValidMovesForPlayer(board.sideToMove) {
+		zeroing := move.is(captureFlag) || move.movedPiece.pieceType == pawn
+		undo := board.movePiece(move)
+		var moveDTZ int
+		if zeroing {
+			childWDL, _, err := tb.search(&board, false)
+			if err != nil {
+				return 0, err
+			}
+			moveDTZ = -dtzBeforeZeroing(childWDL)
+		} else {
+			childDTZ, err := tb.probeDTZ(&board)
+			if err != nil {
+				return 0, err
+			}
+			moveDTZ = -childDTZ
+		}
+		if moveDTZ == 1 && board.playerInCheckMate(board.sideToMove) {
+			best, found = 1, true
+		}
+		board.unmakeMove(undo)
+
+		// A zeroing move's DTZ already counts the move itself
+		if !zeroing {
+			moveDTZ += sign(moveDTZ)
+		}
+		if sign(moveDTZ) == sign(int(wdl)) && (!found || moveDTZ < best) {
+			best, found = moveDTZ, true
+		}
+	}
+	if !found {
+		return -1, nil // Checkmated
+	}
+	return best, nil
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// The moves of the side to move that keep the best result given its fifty-move count: when winning, those that
+// reset the count soonest, and when losing, those that put it off longest
+func (tb *Tablebase) rootMoves(cb *chessBoard) ([]chessMove, error) {
+	if err := tb.checkCovered(cb); err != nil {
+		return nil, err
+	}
+
+	board := cb.deepCopy()
+	var bestMoves []chessMove
+	bestRank := 0
+	for _, move := range board.getAllValidMovesForPlayer(board.sideToMove) {
+		undo := board.movePiece(move)
+		var dtz int
+		if board.halfmoveClock == 0 {
+			wdl, err := tb.probeWDL(&board)
+			if err != nil {
+				return nil, err
+			}
+			dtz = dtzBeforeZeroing(-wdl)
+		} else {
+			childDTZ, err := tb.probeDTZ(&board)
+			if err != nil {
+				return nil, err
+			}
+			dtz = -childDTZ + sign(-childDTZ)
+		}
+		if dtz == 2 && board.playerInCheckMate(board.sideToMove) {
+			dtz = 1
+		}
+		board.unmakeMove(undo)
+
+		// Wins and losses that the fifty-move rule reaches first rank next to draws
+		rank := 0
+		switch {
+		case dtz > 0 && dtz+cb.halfmoveClock <= fiftyMoveRulePlies:
+			rank = 1000 - dtz
+		case dtz > 0:
+			rank = 1
+		case dtz < 0 && -dtz+cb.halfmoveClock <= fiftyMoveRulePlies:
+			rank = -1000 - dtz
+		case dtz < 0:
+			rank = -1
+		}
+		switch {
+		case bestMoves == nil || rank > bestRank:
+			bestMoves, bestRank = []chessMove{move}, rank
+		case rank == bestRank:
+			bestMoves = append(bestMoves, move)
+		}
+	}
+	if bestMoves == nil {
+		return nil, errors.New("there are no legal moves")
+	}
+	return bestMoves, nil
+}
@@ -0,0 +1,176 @@
+package chess
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The official three-piece Syzygy tables (KQvK, KRvK, KBvK, KNvK and KPvK, each .rtbw and .rtbz) as published
+// at https://tablebase.lichess.ovh/tables/standard/3-4-5/
+const syzygyFixtureDir = "testdata/syzygy"
+
+func openFixtureTablebase(t *testing.T) *Tablebase {
+	t.Helper()
+	for _, material := range []string{"KQvK", "KRvK", "KBvK", "KNvK", "KPvK"} {
+		for _, extension := range []string{".rtbw", ".rtbz"} {
+			if _, err := os.Stat(filepath.Join(syzygyFixtureDir, material+extension)); err != nil {
+				t.Skipf("the official Syzygy tables aren't in %s: %v", syzygyFixtureDir, err)
+			}
+		}
+	}
+	tablebase, err := OpenTablebase(syzygyFixtureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tablebase
+}
+
+func TestOpenTablebaseWithoutTables(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), []byte("not a table"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenTablebase(t.TempDir()); err == nil {
+		t.Error("OpenTablebase of an empty directory succeeded")
+	}
+	tablebase, err := OpenTablebase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, _ := NewGameFromFEN("8/8/8/3k4/8/8/8/Q3K3 w - - 0 1")
+	if result, err := tablebase.Probe(game); err == nil {
+		t.Errorf("Probe with a corrupt table = %v, want an error", result)
+	}
+}
+
+func TestTablebaseProbe(t *testing.T) {
+	tablebase := openFixtureTablebase(t)
+	if tablebase.MaxPieces() != 3 {
+		t.Errorf("MaxPieces() = %d, want 3", tablebase.MaxPieces())
+	}
+
+	tests := []struct {
+		name string
+		fen  string
+		want TablebaseResult
+	}{
+		{"mate in one", "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1", TablebaseResult{TablebaseWin, 1}},
+		{"mated", "8/8/8/8/8/5k2/4q3/4K3 w - - 0 1", TablebaseResult{TablebaseLoss, -1}},
+		{"rook hangs", "8/8/8/8/8/8/1k6/1R2K3 b - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		{"bishop", "8/8/3k4/8/8/2B5/8/4K3 w - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		{"knight", "8/8/3k4/8/8/2N5/8/4K3 b - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		{"promotes", "8/4P3/8/8/8/k7/8/4K3 w - - 0 1", TablebaseResult{TablebaseWin, 1}},
+		{"rook pawn", "k7/8/8/8/8/8/P7/K7 w - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		{"stalemate", "4k3/4P3/4K3/8/8/8/8/8 b - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+	}
+	for _, test := range tests {
+		game, err := NewGameFromFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result, err := tablebase.Probe(game); err != nil || result != test.want {
+			t.Errorf("%s: Probe(%s) = %v, %v, want %v", test.name, test.fen, result, err, test.want)
+		}
+	}
+
+	// The king on the sixth rank in front of its pawn wins whoever is to move, for either color
+	for fen, want := range map[string]WDL{
+		"4k3/8/4K3/4P3/8/8/8/8 w - - 0 1": TablebaseWin,
+		"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1": TablebaseLoss,
+		"8/8/8/8/4p3/4k3/8/4K3 b - - 0 1": TablebaseWin,
+		"8/8/8/8/4p3/4k3/8/4K3 w - - 0 1": TablebaseLoss,
+	} {
+		game, _ := NewGameFromFEN(fen)
+		result, err := tablebase.Probe(game)
+		if err != nil || result.WDL != want || (result.DTZ > 0) != (want == TablebaseWin) {
+			t.Errorf("Probe(%s) = %v, %v, want a %v", fen, result, err, want)
+		}
+	}
+
+	for _, fen := range []string{StartingFEN, "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "4k3/8/8/8/8/8/8/RR2K3 w - - 0 1"} {
+		game, _ := NewGameFromFEN(fen)
+		if result, err := tablebase.Probe(game); err == nil {
+			t.Errorf("Probe(%s) = %v, want an error", fen, result)
+		}
+	}
+}
+
+// The longest wins with a queen or a rook take 10 and 16 moves, as the endgame databases have long shown
+func TestTablebaseLongestWins(t *testing.T) {
+	if testing.Short() {
+		t.Skip("probes every position")
+	}
+	tablebase := openFixtureTablebase(t)
+	for pieceType, want := range map[piece]int{queen: 19, rook: 31} {
+		longest := 0
+		for whiteKing := range 64 {
+			for blackKing := range 64 {
+				for square := range 64 {
+					board, ok := endgameBoard(pieceType, whiteKing, blackKing, square)
+					if !ok {
+						continue
+					}
+					dtz, err := tablebase.probeDTZ(&board)
+					if err != nil {
+						t.Fatal(err)
+					}
+					longest = max(longest, dtz)
+				}
+			}
+		}
+		if longest != want {
+			t.Errorf("longest win with a %v is %d plies, want %d", sanPieceLetters[pieceType], longest, want)
+		}
+	}
+}
+
+func TestEnginePlaysFromTablebase(t *testing.T) {
+	tablebase := openFixtureTablebase(t)
+	engine := NewEngine()
+	engine.SetTablebase(tablebase)
+
+	// Both sides play perfectly, so the win takes exactly as long as the tablebase says
+	game, _ := NewGameFromFEN("8/8/8/3k4/8/8/8/R3K3 w - - 0 1")
+	result, err := tablebase.Probe(game)
+	if err != nil || result.WDL != TablebaseWin {
+		t.Fatalf("Probe = %v, %v, want a win", result, err)
+	}
+	for !game.Outcome().IsOver() {
+		moves := make(chan Move, 1)
+		engine.Start(game, SearchLimits{Depth: 1}, nil, func(move Move) { moves <- move })
+		if err := game.Play(<-moves); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if game.Outcome().Termination() != Checkmate || len(game.Moves()) != result.DTZ {
+		t.Errorf("game ended by %v after %d plies, want checkmate after %d", game.Outcome().Termination(), len(game.Moves()), result.DTZ)
+	}
+
+	// The search steers into a won pawn endgame by capturing
+	builtIn := newEngine()
+	builtIn.tablebase = tablebase
+	var board chessBoard
+	board.loadFEN("8/8/8/8/8/2k5/2p5/Kb1R4 w - - 0 1")
+	if move := builtIn.search(&board, searchLimits{depth: 2}, nil); move.String() != "d1b1" {
+		t.Errorf("engine played %v, want d1b1", move)
+	}
+}
+
+// The position with the white king, the black king and one other white piece, with white to move, or false if
+// it isn't legal
+func endgameBoard(pieceType piece, whiteKing int, blackKing int, square int) (chessBoard, bool) {
+	var board chessBoard
+	if whiteKing == blackKing || whiteKing == square || blackKing == square || kingAttacks[whiteKing].has(blackKing) {
+		return board, false
+	}
+	if pieceType == pawn && (square < 8 || square >= 56) {
+		return board, false
+	}
+	board.enpassantSquare = nilSquare
+	board.sideToMove = white
+	board.setPiece(indexToSquare(whiteKing), chessPiece{king, white})
+	board.setPiece(indexToSquare(blackKing), chessPiece{king, black})
+	board.setPiece(indexToSquare(square), chessPiece{pieceType, white})
+	return board, !board.playerInCheck(black)
+}
@@ -0,0 +1,680 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// The first bytes of Syzygy WDL and DTZ files
+var (
+	syzygyWDLMagic = [4]byte{0x71, 0xe8, 0x23, 0x5d}
+	syzygyDTZMagic = [4]byte{0xd7, 0x66, 0x0c, 0xa5}
+)
+
+// Flags in the first byte after a table's magic
+const (
+	syzygySplitFlag    = 1 // The table stores both sides to move separately
+	syzygyHasPawnsFlag = 2
+)
+
+// Flags of the compressed data of one side and pawn file of a table
+const (
+	syzygySideToMoveFlag  = 1 // Black to move, for DTZ tables, which only store one side
+	syzygyMappedFlag      = 2 // DTZ values are looked up in the table's value map
+	syzygyWinPliesFlag    = 4 // DTZ values of wins are in plies rather than moves
+	syzygyLossPliesFlag   = 8 // DTZ values of losses are in plies rather than moves
+	syzygyWideFlag        = 16
+	syzygySingleValueFlag = 128 // Every position has the same value, and there is no compressed data
+)
+
+// A left and right symbol of 12 bits each marks a leaf of the symbol tree, which stands for a single value
+const syzygyLeafSymbol = 0xfff
+
+// Piece codes used by Syzygy files.  Black's are white's plus 8.
+var syzygyPieceCodes = [7]int{pawn: 1, knight: 2, bishop: 3, rook: 4, queen: 5, king: 6}
+
+const syzygyBlackCode = 8
+
+// Index tables shared by all Syzygy tables, built by init
+var (
+	// Squares below the a1-h8 diagonal numbered 0 to 27
+	syzygyMapB1H1H7 [64]int
+	// Squares of the a1-d1-d4 triangle numbered 0 to 9, those on the diagonal last
+	syzygyMapA1D1D4 [64]int
+	// The 462 placements of two kings, the first in the a1-d1-d4 triangle, that aren't mirrors of each other
+	syzygyMapKK [10][64]int
+	// binomial[k][n] is the number of ways to choose k of n things
+	syzygyBinomial [7][64]uint64
+	// Squares from a2 to h7 numbered from 47 down, files from the edge inwards and ranks upwards, so the pawn
+	// with the highest number is the one the table is split by
+	syzygyMapPawns [64]int
+	// Index of the leading pawns by their count and the square of the first, and the number of indices for
+	// each count and file
+	syzygyLeadPawnIdx   [6][64]uint64
+	syzygyLeadPawnsSize [6][4]uint64
+)
+
+// How far the square is above the a1-h8 diagonal, negative below it
+func offDiagonal(square int) int {
+	return square/8 - square%8
+}
+
+func init() {
+	code := 0
+	for square := range 64 {
+		if offDiagonal(square) < 0 {
+			syzygyMapB1H1H7[square] = code
+			code++
+		}
+	}
+
+	code = 0
+	var diagonal []int
+	for square := 0; square <= 27; square++ {
+		if square%8 > 3 {
+			continue
+		}
+		if offDiagonal(square) < 0 {
+			syzygyMapA1D1D4[square] = code
+			code++
+		} else if offDiagonal(square) == 0 {
+			diagonal = append(diagonal, square)
+		}
+	}
+	for _, square := range diagonal {
+		syzygyMapA1D1D4[square] = code
+		code++
+	}
+
+	type kingPair struct{ first, second int }
+	var bothOnDiagonal []kingPair
+	code = 0
+	for first := range 10 {
+		for square1 := 0; square1 <= 27; square1++ {
+			if square1%8 > 3 || syzygyMapA1D1D4[square1] != first || (first == 0 && square1 != 1) {
+				continue
+			}
+			for square2 := range 64 {
+				switch {
+				case abs(square1%8-square2%8) <= 1 && abs(square1/8-square2/8) <= 1:
+					// Kings can't stand on or beside each other
+				case offDiagonal(square1) == 0 && offDiagonal(square2) > 0:
+					// The mirror of a placement with the second king below the diagonal
+				case offDiagonal(square1) == 0 && offDiagonal(square2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, kingPair{first, square2})
+				default:
+					syzygyMapKK[first][square2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, pair := range bothOnDiagonal {
+		syzygyMapKK[pair.first][pair.second] = code
+		code++
+	}
+
+	syzygyBinomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < len(syzygyBinomial) && k <= n; k++ {
+			if k > 0 {
+				syzygyBinomial[k][n] += syzygyBinomial[k-1][n-1]
+			}
+			if k < n {
+				syzygyBinomial[k][n] += syzygyBinomial[k][n-1]
+			}
+		}
+	}
+
+	available := 47
+	for leadPawns := 1; leadPawns < len(syzygyLeadPawnIdx); leadPawns++ {
+		for file := range 4 {
+			index := uint64(0)
+			for rank := 1; rank <= 6; rank++ {
+				square := rank*8 + file
+				if leadPawns == 1 {
+					syzygyMapPawns[square] = available
+					syzygyMapPawns[square^7] = available - 1
+					available -= 2
+				}
+				syzygyLeadPawnIdx[leadPawns][square] = index
+				index += syzygyBinomial[leadPawns-1][syzygyMapPawns[square]]
+			}
+			syzygyLeadPawnsSize[leadPawns][file] = index
+		}
+	}
+}
+
+// A table for one material balance, such as KRvK, with its WDL and DTZ files.  It is named with the side with
+// more material first, and is used for that side playing either color.
+type syzygyTable struct {
+	material        string
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool   // A side has exactly one of a piece other than its king
+	pawnCount       [2]int // Pawns of the side the table is split by, then of the other side
+	symmetric       bool   // Both sides have the same pieces
+	wdl             syzygyFile
+	dtz             syzygyFile
+}
+
+// One of a table's files, read when it is first probed
+type syzygyFile struct {
+	path string // Empty when the tablebases don't include the file
+	dtz  bool
+	once sync.Once
+	err  error
+	// Compressed data by side to move, then by the file of the leading pawn for tables with pawns
+	pairs [2][4]*syzygyPairs
+	// DTZ values looked up by the values stored in tables with the mapped flag
+	valueMap []byte
+}
+
+// Compressed values of one side and leading pawn file of a table
+type syzygyPairs struct {
+	pieces   [maxSyzygyPieces]int // Piece codes in the order their squares are encoded
+	groupLen [maxSyzygyPieces + 1]int
+	groupIdx [maxSyzygyPieces + 1]uint64
+	flags    byte
+	// The value of every position in a table with the single value flag
+	singleValue int
+
+	blockSize  uint64 // Bytes per block
+	span       uint64 // Values between the entries of sparseIndex
+	blockCount int
+	// Number of block lengths, more than blockCount when padded so that the sparse index can't point past them
+	blockLengthSize int
+	minSymLen       int
+	lowestSym       []byte   // Lowest symbol of each code length from minSymLen, little-endian 16 bits each
+	base            []uint64 // Lowest code of each length, left-aligned in 64 bits
+	symLen          []int    // Number of values a symbol stands for, minus one
+	symbolTree      []byte   // Left and right symbols of each symbol, 12 bits each
+	sparseIndex     []byte   // Block and offset in it of every span-th value, 6 bytes each
+	blockLength     []byte   // Number of values in each block minus one, little-endian 16 bits each
+	data            []byte
+	valueMapIdx     [4]int // For mapped DTZ tables, where each result's values start in the file's value map
+}
+
+const maxSyzygyPieces = 7
+
+// The pieces of material names, such as "KRPvKR", strongest first, and their letters
+var (
+	syzygyMaterialPieces  = [6]piece{king, queen, rook, bishop, knight, pawn}
+	syzygyMaterialLetters = "KQRBNP"
+)
+
+// The pieces of color as written in table names, e.g. "KRP"
+func (cb *chessBoard) syzygyMaterial(color pieceColor) string {
+	var material strings.Builder
+	for i, pieceType := range syzygyMaterialPieces {
+		material.WriteString(strings.Repeat(syzygyMaterialLetters[i:i+1], cb.pieceBitboards[color][pieceType].count()))
+	}
+	return material.String()
+}
+
+// Makes the table for a material balance such as "KRPvKR", or returns false if it isn't one
+func newSyzygyTable(material string) (*syzygyTable, bool) {
+	strong, weak, found := strings.Cut(material, "v")
+	if !found {
+		return nil, false
+	}
+	table := &syzygyTable{material: material, pieceCount: len(strong) + len(weak), symmetric: strong == weak}
+	pawns := [2]int{}
+	for side, pieces := range []string{strong, weak} {
+		counts := map[piece]int{}
+		for i := range len(pieces) {
+			letter := strings.IndexByte(syzygyMaterialLetters, pieces[i])
+			if letter == -1 {
+				return nil, false
+			}
+			counts[syzygyMaterialPieces[letter]]++
+		}
+		if counts[king] != 1 || pieces[0] != 'K' {
+			return nil, false
+		}
+		for pieceType, count := range counts {
+			if pieceType != king && count == 1 {
+				table.hasUniquePieces = true
+			}
+		}
+		pawns[side] = counts[pawn]
+	}
+	if table.pieceCount > maxSyzygyPieces {
+		return nil, false
+	}
+
+	table.hasPawns = pawns[0]+pawns[1] > 0
+	// Tables with pawns on both sides are split by the pawns of the side with fewer
+	table.pawnCount = pawns
+	if pawns[1] != 0 && (pawns[0] == 0 || pawns[1] < pawns[0]) {
+		table.pawnCount = [2]int{pawns[1], pawns[0]}
+	}
+	return table, true
+}
+
+func (f *syzygyFile) load(table *syzygyTable) error {
+	f.once.Do(func() {
+		if f.path == "" {
+			kind := "WDL"
+			if f.dtz {
+				kind = "DTZ"
+			}
+			f.err = fmt.Errorf("no %s table for %s", kind, table.material)
+			return
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			f.err = err
+			return
+		}
+		if err := f.parse(table, data); err != nil {
+			f.err = fmt.Errorf("%s: %w", f.path, err)
+		}
+	})
+	return f.err
+}
+
+// Reads a table file.  Stockfish's tbprobe.cpp is the reference for the format.
+func (f *syzygyFile) parse(table *syzygyTable, data []byte) error {
+	magic := syzygyWDLMagic
+	if f.dtz {
+		magic = syzygyDTZMagic
+	}
+	if len(data) < 5 || [4]byte(data[:4]) != magic {
+		return errors.New("not a Syzygy table")
+	}
+	if (data[4]&syzygyHasPawnsFlag != 0) != table.hasPawns || (data[4]&syzygySplitFlag != 0) == table.symmetric {
+		return fmt.Errorf("the table isn't for %s", table.material)
+	}
+
+	r := &syzygyReader{data: data, pos: 5}
+	sides := 1
+	if !f.dtz && !table.symmetric {
+		sides = 2
+	}
+	files := 1
+	if table.hasPawns {
+		files = 4
+	}
+	bothSidesHavePawns := table.hasPawns && table.pawnCount[1] > 0
+
+	for file := range files {
+		for side := range sides {
+			f.pairs[side][file] = &syzygyPairs{}
+		}
+		orderByte := r.byte()
+		secondOrderByte := 0xff
+		if bothSidesHavePawns {
+			secondOrderByte = r.byte()
+		}
+		order := [2][2]int{{orderByte & 0xf, secondOrderByte & 0xf}, {orderByte >> 4, secondOrderByte >> 4}}
+		// Each byte gives a piece of both sides, the side with white to move in the low bits
+		for k := range table.pieceCount {
+			pieceByte := r.byte()
+			f.pairs[0][file].pieces[k] = pieceByte & 0xf
+			if sides == 2 {
+				f.pairs[1][file].pieces[k] = pieceByte >> 4
+			}
+		}
+		for side := range sides {
+			if err := f.pairs[side][file].setGroups(table, order[side], file); err != nil {
+				return err
+			}
+		}
+	}
+	r.align(2)
+
+	for file := range files {
+		for side := range sides {
+			f.pairs[side][file].readSizes(r)
+		}
+	}
+
+	if f.dtz {
+		mapStart := r.pos
+		for file := range files {
+			pairs := f.pairs[0][file]
+			if pairs.flags&syzygyMappedFlag == 0 {
+				continue
+			}
+			if pairs.flags&syzygyWideFlag != 0 {
+				r.align(2)
+				for i := range 4 {
+					pairs.valueMapIdx[i] = (r.pos-mapStart)/2 + 1
+					r.skip(2 * r.uint16())
+				}
+			} else {
+				for i := range 4 {
+					pairs.valueMapIdx[i] = r.pos - mapStart + 1
+					r.skip(r.byte())
+				}
+			}
+		}
+		r.align(2)
+		if r.err == nil {
+			f.valueMap = data[mapStart:r.pos]
+		}
+	}
+
+	for file := range files {
+		for side := range sides {
+			pairs := f.pairs[side][file]
+			pairs.sparseIndex = r.bytes(6 * pairs.sparseIndexSize())
+		}
+	}
+	for file := range files {
+		for side := range sides {
+			pairs := f.pairs[side][file]
+			pairs.blockLength = r.bytes(2 * pairs.blockLengthSize)
+		}
+	}
+	for file := range files {
+		for side := range sides {
+			r.align(64)
+			pairs := f.pairs[side][file]
+			pairs.data = r.bytes(pairs.blockCount * int(pairs.blockSize))
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return nil
+}
+
+// Works out how the pieces are split into groups whose squares are encoded together, and what each group's
+// index is multiplied by.  order gives the position of the leading group, and of the remaining pawns when
+// both sides have pawns, among the groups' factors.
+func (d *syzygyPairs) setGroups(table *syzygyTable, order [2]int, file int) error {
+	n := 0
+	firstLen := 2
+	switch {
+	case table.hasPawns:
+		firstLen = 0
+	case table.hasUniquePieces:
+		firstLen = 3
+	}
+	d.groupLen[0] = 1
+	for i := 1; i < table.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	bothSidesHavePawns := table.hasPawns && table.pawnCount[1] > 0
+	next := 1
+	freeSquares := 64 - d.groupLen[0]
+	if bothSidesHavePawns {
+		next = 2
+		freeSquares -= d.groupLen[1]
+	}
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		if k > maxSyzygyPieces {
+			return errors.New("invalid piece group order")
+		}
+		switch {
+		case k == order[0]:
+			d.groupIdx[0] = idx
+			switch {
+			case table.hasPawns:
+				idx *= syzygyLeadPawnsSize[d.groupLen[0]][file]
+			case table.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]:
+			d.groupIdx[1] = idx
+			idx *= syzygyBinomial[d.groupLen[1]][48-d.groupLen[0]]
+		default:
+			d.groupIdx[next] = idx
+			idx *= syzygyBinomial[d.groupLen[next]][freeSquares]
+			freeSquares -= d.groupLen[next]
+			next++
+		}
+	}
+	d.groupIdx[n] = idx
+	return nil
+}
+
+// Number of positions indexed, the last group factor
+func (d *syzygyPairs) size() uint64 {
+	return d.groupIdx[slices.Index(d.groupLen[:], 0)]
+}
+
+func (d *syzygyPairs) sparseIndexSize() int {
+	if d.flags&syzygySingleValueFlag != 0 {
+		return 0
+	}
+	return int((d.size() + d.span - 1) / d.span)
+}
+
+// Reads the sizes of the compressed data and the canonical Huffman code and symbol tree it is written with
+func (d *syzygyPairs) readSizes(r *syzygyReader) {
+	d.flags = byte(r.byte())
+	if d.flags&syzygySingleValueFlag != 0 {
+		d.singleValue = r.byte()
+		return
+	}
+
+	d.blockSize = 1 << r.byte()
+	d.span = 1 << r.byte()
+	padding := r.byte()
+	d.blockCount = int(r.uint32())
+	d.blockLengthSize = d.blockCount + padding
+	maxSymLen := r.byte()
+	d.minSymLen = r.byte()
+	if maxSymLen < d.minSymLen || maxSymLen > 64 {
+		r.fail()
+		return
+	}
+	lengths := maxSymLen - d.minSymLen + 1
+	d.lowestSym = r.bytes(2 * lengths)
+	if r.err != nil {
+		return
+	}
+
+	// Codes are ordered so that longer ones have lower values, and all the codes of a length are consecutive.
+	// base[i] is the lowest code of length minSymLen+i, padded on the right to 64 bits.
+	d.base = make([]uint64, lengths)
+	for i := lengths - 2; i >= 0; i-- {
+		d.base[i] = (d.base[i+1] + uint64(d.lowestSymbol(i)) - uint64(d.lowestSymbol(i+1))) / 2
+	}
+	for i := range d.base {
+		d.base[i] <<= 64 - i - d.minSymLen
+	}
+
+	symbols := r.uint16()
+	d.symbolTree = r.bytes(3 * symbols)
+	r.skip(symbols & 1)
+	if r.err != nil {
+		return
+	}
+	d.symLen = make([]int, symbols)
+	visited := make([]bool, symbols)
+	for symbol := range symbols {
+		if !visited[symbol] && !d.setSymLen(symbol, visited) {
+			r.fail()
+			return
+		}
+	}
+}
+
+// Works out how many values a symbol stands for by expanding it.  Returns false for a broken tree.
+func (d *syzygyPairs) setSymLen(symbol int, visited []bool) bool {
+	visited[symbol] = true
+	left, right := d.children(symbol)
+	if right == syzygyLeafSymbol {
+		return true
+	}
+	for _, child := range []int{left, right} {
+		if child >= len(d.symLen) {
+			return false
+		}
+		if !visited[child] && !d.setSymLen(child, visited) {
+			return false
+		}
+	}
+	d.symLen[symbol] = d.symLen[left] + d.symLen[right] + 1
+	return true
+}
+
+// The symbols a symbol expands into, or for a leaf its value and syzygyLeafSymbol
+func (d *syzygyPairs) children(symbol int) (int, int) {
+	lr := d.symbolTree[3*symbol : 3*symbol+3]
+	return int(lr[1]&0xf)<<8 | int(lr[0]), int(lr[2])<<4 | int(lr[1]>>4)
+}
+
+func (d *syzygyPairs) lowestSymbol(length int) int {
+	return int(binary.LittleEndian.Uint16(d.lowestSym[2*length:]))
+}
+
+func (d *syzygyPairs) blockLengthAt(block int) int {
+	return int(binary.LittleEndian.Uint16(d.blockLength[2*block:]))
+}
+
+// Decompresses the value at index idx
+func (d *syzygyPairs) value(idx uint64) (int, error) {
+	if d.flags&syzygySingleValueFlag != 0 {
+		return d.singleValue, nil
+	}
+	corrupt := errors.New("corrupt Syzygy table")
+
+	// The sparse index gives the block and offset of the value in the middle of each span, from which the
+	// blocks are walked to the one holding idx
+	k := idx / d.span
+	if int(k) >= d.sparseIndexSize() {
+		return 0, corrupt
+	}
+	entry := d.sparseIndex[6*k : 6*k+6]
+	block := int(binary.LittleEndian.Uint32(entry))
+	offset := int(binary.LittleEndian.Uint16(entry[4:])) + int(idx%d.span) - int(d.span/2)
+	for offset < 0 {
+		block--
+		if block < 0 {
+			return 0, corrupt
+		}
+		offset += d.blockLengthAt(block) + 1
+	}
+	for block < d.blockLengthSize && offset > d.blockLengthAt(block) {
+		offset -= d.blockLengthAt(block) + 1
+		block++
+	}
+	if block >= d.blockCount {
+		return 0, corrupt
+	}
+
+	// Read symbols from the start of the block until the one standing for the value at offset.  Codes are
+	// big-endian and read into the top of a 64 bit buffer.
+	data := d.data[uint64(block)*d.blockSize:]
+	read32 := func(at int) uint64 {
+		if at+4 > len(data) {
+			return 0 // The last block's final code may end within a word
+		}
+		return uint64(binary.BigEndian.Uint32(data[at:]))
+	}
+	buffer := read32(0)<<32 | read32(4)
+	bufferBits, next := 64, 8
+	var symbol int
+	for {
+		length := 0
+		for length < len(d.base)-1 && buffer < d.base[length] {
+			length++
+		}
+		symbol = int((buffer-d.base[length])>>(64-length-d.minSymLen)) + d.lowestSymbol(length)
+		if symbol >= len(d.symLen) {
+			return 0, corrupt
+		}
+		if offset < d.symLen[symbol]+1 {
+			break
+		}
+		offset -= d.symLen[symbol] + 1
+		length += d.minSymLen
+		buffer <<= length
+		bufferBits -= length
+		if bufferBits <= 32 {
+			bufferBits += 32
+			buffer |= read32(next) << (64 - bufferBits)
+			next += 4
+		}
+	}
+
+	// The symbol stands for a run of values made by pairing symbols.  Descend the pairs to the value.
+	for d.symLen[symbol] != 0 {
+		left, right := d.children(symbol)
+		if offset < d.symLen[left]+1 {
+			symbol = left
+		} else {
+			offset -= d.symLen[left] + 1
+			symbol = right
+		}
+	}
+	value, _ := d.children(symbol)
+	return value, nil
+}
+
+// Reads the little-endian numbers and byte ranges of a table file.  Reading past the end sets err.
+type syzygyReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *syzygyReader) fail() {
+	if r.err == nil {
+		r.err = errors.New("corrupt or truncated Syzygy table")
+	}
+}
+
+func (r *syzygyReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.fail()
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *syzygyReader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *syzygyReader) byte() int {
+	if b := r.bytes(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (r *syzygyReader) uint16() int {
+	if b := r.bytes(2); b != nil {
+		return int(binary.LittleEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *syzygyReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// Skips to the next multiple of n bytes from the start of the file
+func (r *syzygyReader) align(n int) {
+	if r.pos%n != 0 {
+		r.skip(n - r.pos%n)
+	}
+}
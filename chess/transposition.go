@@ -0,0 +1,87 @@
+package chess
+
+type ttBound uint8
+
+const (
+	exactBound ttBound = iota
+	lowerBound         // The search failed high: the score is at least this
+	upperBound         // The search failed low: the score is at most this
+)
+
+// A move packed into 16 bits: from square index, to square index and promotion piece
+type packedMove uint16
+
+const noPackedMove packedMove = 0 // a1a1 can't be a move
+
+func packMove(move chessMove) packedMove {
+	if move.from == nilSquare {
+		return noPackedMove
+	}
+	return packedMove(squareIndex(move.from) | squareIndex(move.to)<<6 | int(move.promotion)<<12)
+}
+
+// Size of a ttEntry in bytes
+const ttEntrySize = 16
+
+type ttEntry struct {
+	hash  uint64
+	score int32
+	move  packedMove
+	depth int8
+	bound ttBound
+}
+
+// Fixed size hash table of search results, indexed by Zobrist hash.  Newer entries replace older ones in
+// the same slot unless the older entry for the same position was searched deeper.
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+func newTranspositionTable(sizeMB int) transpositionTable {
+	// Round down to a power of two so the slot is a mask of the hash
+	entryCount := uint64(1)
+	for entryCount*2*ttEntrySize <= uint64(sizeMB)<<20 {
+		entryCount *= 2
+	}
+	return transpositionTable{entries: make([]ttEntry, entryCount), mask: entryCount - 1}
+}
+
+func (tt *transpositionTable) probe(hash uint64) (ttEntry, bool) {
+	entry := tt.entries[hash&tt.mask]
+	return entry, entry.hash == hash
+}
+
+func (tt *transpositionTable) store(hash uint64, depth int, score int, bound ttBound, move packedMove) {
+	slot := &tt.entries[hash&tt.mask]
+	if slot.hash == hash && int(slot.depth) > depth && bound != exactBound {
+		return
+	}
+	*slot = ttEntry{hash: hash, score: int32(score), move: move, depth: int8(depth), bound: bound}
+}
+
+func (tt *transpositionTable) clear() {
+	clear(tt.entries)
+}
+
+// Mate scores count plies from the root, but a table entry can be reached at any ply, so they are stored
+// relative to the position itself
+func scoreToTT(score int, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score + ply
+	case score <= -mateThreshold:
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score int, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score - ply
+	case score <= -mateThreshold:
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,361 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	uciEngineName = "It's Chess"
+
+	maxTranspositionTableMB = 4096
+	// Moves assumed to be left until the next time control when the GUI doesn't say
+	defaultMovesToGo = 30
+	// Time kept back from every move for the GUI to receive it
+	moveOverhead   = 50 * time.Millisecond
+	minimumUCITime = 10 * time.Millisecond
+)
+
+// A connection to a GUI speaking the Universal Chess Interface
+type uciSession struct {
+	board  chessBoard
+	engine *engine
+	// Set by the UCI_Chess960 option: castling is written as the king taking its own rook
+	chess960 bool
+	// Set by the BookFile option, and played from without searching unless the search is infinite
+	book          *OpeningBook
+	bookSelection BookSelection
+
+	out     io.Writer
+	outLock sync.Mutex // Held while writing a line, as searches write from their own goroutine
+
+	// Set while a search started by "go" hasn't sent its best move yet
+	searching bool
+	// Closed by "stop" to release the best move of an infinite search
+	stopRequested chan struct{}
+	searchDone    chan struct{}
+}
+
+// Arguments of the "go" command
+type uciGoParams struct {
+	depth          int
+	moveTime       time.Duration
+	whiteTime      time.Duration
+	blackTime      time.Duration
+	whiteIncrement time.Duration
+	blackIncrement time.Duration
+	movesToGo      int
+	infinite       bool
+}
+
+// Runs the engine as a UCI engine, reading commands from in and writing responses to out until "quit" or
+// the end of in
+func RunUCI(in io.Reader, out io.Writer) error {
+	session := &uciSession{engine: newEngine(), out: out}
+	session.board.init()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" {
+			break
+		}
+		if err := session.handleCommand(fields[0], fields[1:]); err != nil {
+			session.send("info string %v", err)
+		}
+	}
+
+	session.stopSearch()
+	return scanner.Err()
+}
+
+func (s *uciSession) handleCommand(command string, args []string) error {
+	switch command {
+	case "uci":
+		s.send("id name %s", uciEngineName)
+		s.send("id author The It's Chess authors")
+		s.send("option name Hash type spin default %d min 1 max %d", defaultTranspositionTableMB, maxTranspositionTableMB)
+		s.send("option name Clear Hash type button")
+		s.send("option name Weights type string default <empty>")
+		s.send("option name UCI_Chess960 type check default false")
+		s.send("option name BookFile type string default <empty>")
+		s.send("option name BestBookMove type check default false")
+		s.send("option name SyzygyPath type string default <empty>")
+		s.send("uciok")
+	case "isready":
+		s.send("readyok")
+	case "setoption":
+		s.stopSearch()
+		return s.setOption(args)
+	case "ucinewgame":
+		s.stopSearch()
+		s.engine.reset()
+		s.board.init()
+		s.board.chess960 = s.chess960
+	case "position":
+		s.stopSearch()
+		return s.setPosition(args)
+	case "go":
+		s.stopSearch()
+		params, err := parseUCIGoParams(args)
+		if err != nil {
+			return err
+		}
+		s.startSearch(params)
+	case "stop":
+		s.stopSearch()
+	case "debug", "ponderhit":
+		// Not supported, and safe to ignore
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+// Writes one line to the GUI
+func (s *uciSession) send(format string, args ...any) {
+	s.outLock.Lock()
+	defer s.outLock.Unlock()
+	fmt.Fprintf(s.out, format+"\n", args...)
+}
+
+// Handles "setoption name <name> [value <value>]", where both name and value may contain spaces
+func (s *uciSession) setOption(args []string) error {
+	if len(args) == 0 || args[0] != "name" {
+		return fmt.Errorf("setoption: expected \"name\"")
+	}
+	name, value := strings.Join(args[1:], " "), ""
+	if valueAt := slices.Index(args, "value"); valueAt != -1 {
+		name, value = strings.Join(args[1:valueAt], " "), strings.Join(args[valueAt+1:], " ")
+	}
+
+	switch strings.ToLower(name) {
+	case "hash":
+		sizeMB, err := strconv.Atoi(value)
+		if err != nil || sizeMB < 1 || sizeMB > maxTranspositionTableMB {
+			return fmt.Errorf("setoption: Hash must be between 1 and %d, got %q", maxTranspositionTableMB, value)
+		}
+		s.engine.table = newTranspositionTable(sizeMB)
+	case "clear hash":
+		s.engine.table.clear()
+	case "weights":
+		if value == "" || value == "<empty>" {
+			s.engine.evaluator = defaultEvaluator
+			return nil
+		}
+		evaluator, err := loadEvaluator(value)
+		if err != nil {
+			return fmt.Errorf("setoption: %w", err)
+		}
+		s.engine.evaluator = evaluator
+	case "bookfile":
+		if value == "" || value == "<empty>" {
+			s.book = nil
+			return nil
+		}
+		book, err := LoadOpeningBook(value)
+		if err != nil {
+			return fmt.Errorf("setoption: %w", err)
+		}
+		s.book = book
+	case "bestbookmove":
+		switch value {
+		case "true":
+			s.bookSelection = BestBookMove
+		case "false":
+			s.bookSelection = WeightedBookMove
+		default:
+			return fmt.Errorf("setoption: BestBookMove must be true or false, got %q", value)
+		}
+	case "syzygypath":
+		if value == "" || value == "<empty>" {
+			s.engine.tablebase = nil
+			return nil
+		}
+		tablebase, err := OpenTablebase(value)
+		if err != nil {
+			return fmt.Errorf("setoption: %w", err)
+		}
+		s.engine.tablebase = tablebase
+	case "uci_chess960":
+		switch value {
+		case "true":
+			s.chess960 = true
+		case "false":
+			s.chess960 = false
+		default:
+			return fmt.Errorf("setoption: UCI_Chess960 must be true or false, got %q", value)
+		}
+		s.board.chess960 = s.chess960
+	default:
+		return fmt.Errorf("setoption: unknown option %q", name)
+	}
+	return nil
+}
+
+// Handles "position startpos|fen <fen> [moves <move>...]".  The position is left unchanged if any of it is
+// invalid.
+func (s *uciSession) setPosition(args []string) error {
+	movesAt := slices.Index(args, "moves")
+	if movesAt == -1 {
+		movesAt = len(args)
+	}
+
+	var board chessBoard
+	switch {
+	case len(args) > 0 && args[0] == "startpos":
+		board.init()
+	case len(args) > 0 && args[0] == "fen":
+		if err := board.loadFEN(strings.Join(args[1:movesAt], " ")); err != nil {
+			return fmt.Errorf("position: %w", err)
+		}
+	default:
+		return fmt.Errorf("position: expected \"startpos\" or \"fen\"")
+	}
+	board.chess960 = s.chess960
+
+	for _, notation := range args[min(movesAt+1, len(args)):] {
+		move, err := board.parseMove(notation)
+		if err != nil {
+			return fmt.Errorf("position: %w", err)
+		}
+		board.movePiece(move)
+	}
+
+	s.board = board
+	return nil
+}
+
+func parseUCIGoParams(args []string) (uciGoParams, error) {
+	var params uciGoParams
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			params.infinite = true
+			continue
+		}
+
+		var target any
+		switch args[i] {
+		case "depth":
+			target = &params.depth
+		case "movestogo":
+			target = &params.movesToGo
+		case "movetime":
+			target = &params.moveTime
+		case "wtime":
+			target = &params.whiteTime
+		case "btime":
+			target = &params.blackTime
+		case "winc":
+			target = &params.whiteIncrement
+		case "binc":
+			target = &params.blackIncrement
+		default:
+			// Other arguments, such as nodes or searchmoves, aren't supported
+			continue
+		}
+
+		if i+1 == len(args) {
+			return params, fmt.Errorf("go: missing value for %s", args[i])
+		}
+		i++
+		value, err := strconv.Atoi(args[i])
+		if err != nil {
+			return params, fmt.Errorf("go: %s must be an integer, got %q", args[i-1], args[i])
+		}
+		switch target := target.(type) {
+		case *int:
+			*target = value
+		case *time.Duration:
+			*target = time.Duration(value) * time.Millisecond
+		}
+	}
+	return params, nil
+}
+
+// Decides how long to think, spreading the time left on the clock over the moves until the next time control
+func (p uciGoParams) searchLimits(sideToMove pieceColor) searchLimits {
+	limits := searchLimits{depth: p.depth}
+	if p.infinite {
+		return limits
+	}
+	limits.moveTime = p.moveTime
+
+	timeLeft, increment := p.whiteTime, p.whiteIncrement
+	if sideToMove == black {
+		timeLeft, increment = p.blackTime, p.blackIncrement
+	}
+	if limits.moveTime == 0 && timeLeft > 0 {
+		movesToGo := p.movesToGo
+		if movesToGo <= 0 {
+			movesToGo = defaultMovesToGo
+		}
+		limits.moveTime = timeLeft/time.Duration(movesToGo) + increment*3/4
+		limits.moveTime = min(limits.moveTime, timeLeft-moveOverhead)
+	}
+	if limits.moveTime != 0 {
+		limits.moveTime = max(limits.moveTime, minimumUCITime)
+	}
+	return limits
+}
+
+func (s *uciSession) startSearch(params uciGoParams) {
+	if s.book != nil && !params.infinite {
+		if move, ok := s.book.chooseBoardMove(&s.board, s.bookSelection); ok {
+			s.send("bestmove %s", s.board.moveOf(move))
+			return
+		}
+	}
+
+	stopRequested, searchDone := make(chan struct{}), make(chan struct{})
+	s.searching, s.stopRequested, s.searchDone = true, stopRequested, searchDone
+
+	s.engine.start(&s.board, nil, params.searchLimits(s.board.sideToMove), s.sendSearchInfo, func(move chessMove) {
+		// An infinite search may only send its move once told to stop
+		if params.infinite {
+			<-stopRequested
+		}
+		if move == nilMove {
+			s.send("bestmove 0000")
+		} else {
+			s.send("bestmove %s", s.board.moveOf(move))
+		}
+		close(searchDone)
+	})
+}
+
+// Stops the running search, if there is one, and waits for it to send its best move
+func (s *uciSession) stopSearch() {
+	if !s.searching {
+		return
+	}
+	s.engine.stop()
+	close(s.stopRequested)
+	<-s.searchDone
+	s.searching = false
+}
+
+func (s *uciSession) sendSearchInfo(info searchInfo) {
+	score := fmt.Sprintf("cp %d", info.score)
+	if mate := info.mateIn(); mate != 0 {
+		score = fmt.Sprintf("mate %d", mate)
+	}
+	nps := 0
+	if info.elapsed > 0 {
+		nps = int(float64(info.nodes) / info.elapsed.Seconds())
+	}
+	pv := make([]string, len(info.pv))
+	for i, move := range info.pv {
+		pv[i] = s.board.moveOf(move).String()
+	}
+	s.send("info depth %d score %s nodes %d nps %d time %d pv %s", info.depth, score, info.nodes, nps, info.elapsed.Milliseconds(), strings.Join(pv, " "))
+}
@@ -0,0 +1,129 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A UCI session driven through pipes, as a GUI would
+type uciTestSession struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+	done  chan error
+}
+
+func startUCISession(t *testing.T) *uciTestSession {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	session := &uciTestSession{t: t, in: inWriter, lines: make(chan string, 100), done: make(chan error, 1)}
+
+	go func() {
+		err := RunUCI(inReader, outWriter)
+		outWriter.Close()
+		session.done <- err
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			session.lines <- scanner.Text()
+		}
+		close(session.lines)
+	}()
+	t.Cleanup(func() { inWriter.Close() })
+	return session
+}
+
+func (s *uciTestSession) send(format string, args ...any) {
+	s.t.Helper()
+	if _, err := fmt.Fprintf(s.in, format+"\n", args...); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// Reads lines until one starting with prefix, and returns the lines read including it
+func (s *uciTestSession) expect(prefix string) []string {
+	s.t.Helper()
+	var lines []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("output ended waiting for %q after %q", prefix, lines)
+			}
+			lines = append(lines, line)
+			if strings.HasPrefix(line, prefix) {
+				return lines
+			}
+		case <-timeout:
+			s.t.Fatalf("no %q within 5s, got %q", prefix, lines)
+		}
+	}
+}
+
+// Reads lines up to the best move and checks it is legal in game, returning the lines read
+func (s *uciTestSession) expectLegalBestMove(game *Game) []string {
+	s.t.Helper()
+	lines := s.expect("bestmove ")
+	fields := strings.Fields(lines[len(lines)-1])
+	move, err := game.ParseMove(fields[1])
+	if err != nil {
+		s.t.Errorf("best move: %v", err)
+	} else if !game.IsLegal(move) {
+		s.t.Errorf("best move %s is not legal", move)
+	}
+	return lines
+}
+
+func TestRunUCI(t *testing.T) {
+	session := startUCISession(t)
+
+	session.send("uci")
+	if lines := session.expect("uciok"); lines[0] != "id name "+uciEngineName {
+		t.Errorf("uci answered %q", lines)
+	}
+	session.send("isready")
+	session.expect("readyok")
+
+	game := NewGame()
+	playMoves(t, game, "e2e4", "e7e5", "g1f3")
+	session.send("position startpos moves e2e4 e7e5 g1f3")
+	session.send("go depth 3")
+	if lines := session.expectLegalBestMove(game); !strings.HasPrefix(lines[0], "info depth 1 ") {
+		t.Errorf("search reported %q", lines)
+	}
+
+	// An infinite search only answers once stopped
+	session.send("go infinite")
+	time.Sleep(100 * time.Millisecond)
+	session.send("isready")
+	if lines := session.expect("readyok"); len(lines) == 0 || strings.HasPrefix(lines[0], "bestmove") {
+		t.Errorf("infinite search answered before stop: %q", lines)
+	}
+	session.send("stop")
+	session.expectLegalBestMove(game)
+
+	session.send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	session.send("go movetime 200")
+	if lines := session.expect("bestmove "); lines[len(lines)-1] != "bestmove a1a8" {
+		t.Errorf("missed mate in one: %q", lines)
+	}
+
+	session.send("position startpos moves e2e5")
+	session.expect("info string ")
+
+	session.send("quit")
+	select {
+	case err := <-session.done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunUCI didn't return after quit")
+	}
+}
@@ -0,0 +1,279 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long an external engine may take to answer "uci" and "isready" before it is given up on
+const uciHandshakeTimeout = 10 * time.Second
+
+// An external engine program, such as Stockfish, spoken to over the Universal Chess Interface.  Like the
+// built-in engine, it runs one search at a time.
+type uciEngine struct {
+	name    string
+	process *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string // Lines written by the engine, closed when it exits
+	// Whether the engine has been told to play Chess960
+	chess960 bool
+	// Closed once the last search started has read its best move, nil before the first
+	searchDone chan struct{}
+}
+
+// Starts the engine program at path and waits for it to be ready
+func startUCIEngine(path string, args ...string) (*uciEngine, error) {
+	process := exec.Command(path, args...)
+	stdin, err := process.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := process.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := process.Start(); err != nil {
+		return nil, fmt.Errorf("starting UCI engine: %w", err)
+	}
+
+	ue := &uciEngine{name: path, process: process, stdin: stdin, lines: make(chan string)}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			ue.lines <- scanner.Text()
+		}
+		close(ue.lines)
+	}()
+
+	if err := ue.handshake(); err != nil {
+		ue.Close()
+		return nil, fmt.Errorf("UCI engine %s: %w", path, err)
+	}
+	return ue, nil
+}
+
+func (ue *uciEngine) handshake() error {
+	timeout := time.After(uciHandshakeTimeout)
+	waitFor := func(response string) error {
+		for {
+			select {
+			case line, ok := <-ue.lines:
+				if !ok {
+					return fmt.Errorf("exited before sending %q", response)
+				}
+				if name, found := strings.CutPrefix(line, "id name "); found {
+					ue.name = name
+				}
+				if strings.TrimSpace(line) == response {
+					return nil
+				}
+			case <-timeout:
+				return fmt.Errorf("no %q within %v", response, uciHandshakeTimeout)
+			}
+		}
+	}
+
+	if err := ue.send("uci"); err != nil {
+		return err
+	}
+	if err := waitFor("uciok"); err != nil {
+		return err
+	}
+	if err := ue.send("isready"); err != nil {
+		return err
+	}
+	return waitFor("readyok")
+}
+
+func (ue *uciEngine) send(command string) error {
+	_, err := io.WriteString(ue.stdin, command+"\n")
+	return err
+}
+
+// Starts a search of board and returns straight away.  The engine's info lines are passed to report as they
+// arrive, and done is called with its best move, or nilMove if it has none or stops responding.  The engine is
+// given the game's starting position and moves from record, so that it knows about repetitions of earlier
+// positions, or the position alone without one.  A search still running is stopped first, and start waits for
+// its best move so that it can't be taken for the new search's.
+func (ue *uciEngine) start(board *chessBoard, record *gameRecord, limits searchLimits, report func(searchInfo), done func(chessMove)) {
+	if ue.searchDone != nil {
+		select {
+		case <-ue.searchDone:
+		default:
+			ue.stop()
+			<-ue.searchDone
+		}
+	}
+	boardCopy := board.deepCopy()
+
+	goCommand := "go"
+	if limits.depth > 0 {
+		goCommand += fmt.Sprintf(" depth %d", limits.depth)
+	}
+	if limits.moveTime > 0 {
+		goCommand += fmt.Sprintf(" movetime %d", limits.moveTime.Milliseconds())
+	}
+	if limits == (searchLimits{}) {
+		goCommand += " infinite"
+	}
+
+	// Engines that don't support Chess960 ignore the option, and castle the standard way
+	if board.chess960 != ue.chess960 {
+		ue.chess960 = board.chess960
+		if err := ue.send(fmt.Sprintf("setoption name UCI_Chess960 value %t", ue.chess960)); err != nil {
+			go done(nilMove)
+			return
+		}
+	}
+	if err := ue.send(uciPositionCommand(board, record)); err != nil {
+		go done(nilMove)
+		return
+	}
+	if err := ue.send(goCommand); err != nil {
+		go done(nilMove)
+		return
+	}
+
+	searchDone := make(chan struct{})
+	ue.searchDone = searchDone
+	go func() {
+		for line := range ue.lines {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch fields[0] {
+			case "info":
+				if info, ok := boardCopy.parseUCIInfo(fields[1:]); ok && report != nil {
+					report(info)
+				}
+			case "bestmove":
+				move := nilMove
+				if len(fields) > 1 {
+					if parsed, err := boardCopy.parseMove(fields[1]); err == nil {
+						move = parsed
+					}
+				}
+				close(searchDone)
+				done(move)
+				return
+			}
+		}
+		close(searchDone)
+		done(nilMove)
+	}()
+}
+
+// The command that gives an engine the game in record, which leads to board, or board alone if record is nil
+func uciPositionCommand(board *chessBoard, record *gameRecord) string {
+	if record == nil {
+		return "position fen " + board.toFEN()
+	}
+	command := "position fen " + record.startFEN
+	if len(record.moves) > 0 {
+		moves := make([]string, len(record.moves))
+		for i, move := range record.moves {
+			moves[i] = board.moveOf(move).String()
+		}
+		command += " moves " + strings.Join(moves, " ")
+	}
+	return command
+}
+
+// Tells the engine to send its best move as soon as possible
+func (ue *uciEngine) stop() {
+	ue.send("stop")
+}
+
+// Asks the engine to quit, killing it if it doesn't within a second
+func (ue *uciEngine) Close() error {
+	ue.send("quit")
+	ue.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		// The output has to be drained for the engine to be able to exit
+		for range ue.lines {
+		}
+		exited <- ue.process.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(time.Second):
+		ue.process.Process.Kill()
+		return <-exited
+	}
+}
+
+// Parses the arguments of an info line about board into a searchInfo.  Lines that don't report a scored
+// search depth, such as "info currmove" or "info string", are not parsed.
+func (cb *chessBoard) parseUCIInfo(args []string) (searchInfo, bool) {
+	var info searchInfo
+	hasDepth, hasScore := false, false
+
+	intAt := func(i int) (int, bool) {
+		if i >= len(args) {
+			return 0, false
+		}
+		value, err := strconv.Atoi(args[i])
+		return value, err == nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "depth":
+			info.depth, hasDepth = intAt(i + 1)
+			i++
+		case "nodes":
+			info.nodes, _ = intAt(i + 1)
+			i++
+		case "time":
+			milliseconds, _ := intAt(i + 1)
+			info.elapsed = time.Duration(milliseconds) * time.Millisecond
+			i++
+		case "score":
+			if i+2 >= len(args) {
+				return info, false
+			}
+			value, ok := intAt(i + 2)
+			if !ok {
+				return info, false
+			}
+			switch args[i+1] {
+			case "cp":
+				info.score, hasScore = value, true
+			case "mate":
+				// The inverse of searchInfo.mateIn: mating takes 2n-1 plies, being mated 2n
+				if value > 0 {
+					info.score = mateScore - (2*value - 1)
+				} else {
+					info.score = -mateScore - 2*value
+				}
+				hasScore = true
+			}
+			i += 2
+		case "pv":
+			// The pv runs to the end of the line
+			board := cb.deepCopy()
+			for _, notation := range args[i+1:] {
+				move, err := board.parseMove(notation)
+				if err != nil {
+					break
+				}
+				info.pv = append(info.pv, move)
+				board.movePiece(move)
+			}
+			i = len(args)
+		case "string":
+			return info, false
+		}
+	}
+
+	return info, hasDepth && hasScore
+}
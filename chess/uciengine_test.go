@@ -0,0 +1,255 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Set in the environment of the test binary when it is started as the fake engine
+const fakeUCIEngineEnv = "ITSCHESS_FAKE_UCI_ENGINE"
+
+// A file the fake engine writes the position commands it is sent to, if set in its environment
+const fakeUCIEngineLogEnv = "ITSCHESS_FAKE_UCI_ENGINE_LOG"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeUCIEngineEnv) != "" {
+		runFakeUCIEngine()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// A stand-in for a real UCI engine that always plays the first legal move.  It reports one info line per
+// search, and an infinite search holds its move back until "stop".
+func runFakeUCIEngine() {
+	var board chessBoard
+	board.init()
+	searching := false
+	var positionLog *os.File
+	if path := os.Getenv(fakeUCIEngineLogEnv); path != "" {
+		positionLog, _ = os.Create(path)
+		defer positionLog.Close()
+	}
+
+	bestMove := func() {
+		fmt.Printf("bestmove %s\n", board.getAllValidMovesForPlayer(board.sideToMove)[0])
+		searching = false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name Fake Engine")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			if positionLog != nil {
+				fmt.Fprintln(positionLog, scanner.Text())
+			}
+			fen, moves, _ := strings.Cut(strings.Join(fields[1:], " "), " moves ")
+			if fen == "startpos" {
+				board.init()
+			} else {
+				board.loadFEN(strings.TrimPrefix(fen, "fen "))
+			}
+			for _, notation := range strings.Fields(moves) {
+				if move, err := board.parseMove(notation); err == nil {
+					board.movePiece(move)
+				}
+			}
+		case "go":
+			move := board.getAllValidMovesForPlayer(board.sideToMove)[0]
+			fmt.Printf("info currmove %s currmovenumber 1\n", move)
+			fmt.Printf("info depth 3 seldepth 5 score cp -25 nodes 1234 time 56 pv %s\n", move)
+			searching = true
+			if fields[len(fields)-1] != "infinite" {
+				bestMove()
+			}
+		case "stop":
+			if searching {
+				bestMove()
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func startFakeUCIEngine(t *testing.T) *uciEngine {
+	t.Setenv(fakeUCIEngineEnv, "1")
+	engine, err := startUCIEngine(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+// Runs a search and returns its info reports and best move
+func searchWith(t *testing.T, engine moveSearcher, board *chessBoard, record *gameRecord, limits searchLimits, stopAfter time.Duration) ([]searchInfo, chessMove) {
+	infos := make(chan searchInfo, 10)
+	moves := make(chan chessMove, 1)
+	engine.start(board, record, limits, func(info searchInfo) { infos <- info }, func(move chessMove) { moves <- move })
+
+	if stopAfter > 0 {
+		time.Sleep(stopAfter)
+		engine.stop()
+	}
+	select {
+	case move := <-moves:
+		close(infos)
+		var reports []searchInfo
+		for info := range infos {
+			reports = append(reports, info)
+		}
+		return reports, move
+	case <-time.After(5 * time.Second):
+		t.Fatal("no best move within 5s")
+		return nil, nilMove
+	}
+}
+
+func TestUCIEngineHandshake(t *testing.T) {
+	engine := startFakeUCIEngine(t)
+	if engine.name != "Fake Engine" {
+		t.Errorf("engine name = %q, want %q", engine.name, "Fake Engine")
+	}
+}
+
+func TestUCIEngineSearch(t *testing.T) {
+	engine := startFakeUCIEngine(t)
+
+	var board chessBoard
+	if err := board.loadFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	want := board.getAllValidMovesForPlayer(board.sideToMove)[0]
+
+	infos, move := searchWith(t, engine, &board, nil, searchLimits{depth: 3}, 0)
+	if move != want {
+		t.Errorf("best move = %s, want %s", move, want)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("got %d info reports, want 1", len(infos))
+	}
+	info := infos[0]
+	if info.depth != 3 || info.score != -25 || info.nodes != 1234 || info.elapsed != 56*time.Millisecond {
+		t.Errorf("info = %+v, want depth 3, score -25, 1234 nodes in 56ms", info)
+	}
+	if len(info.pv) != 1 || info.pv[0] != want {
+		t.Errorf("pv = %v, want [%s]", info.pv, want)
+	}
+
+	// The engine can search again after a search has finished
+	if err := board.loadFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+	if _, move := searchWith(t, engine, &board, nil, searchLimits{moveTime: time.Second}, 0); !board.isValidMove(move) {
+		t.Errorf("best move %s is not legal from the initial position", move)
+	}
+}
+
+func TestUCIEngineStop(t *testing.T) {
+	engine := startFakeUCIEngine(t)
+
+	var board chessBoard
+	board.init()
+	if _, move := searchWith(t, engine, &board, nil, searchLimits{}, 50*time.Millisecond); !board.isValidMove(move) {
+		t.Errorf("best move %s is not legal from the initial position", move)
+	}
+}
+
+// A search started while another runs gets its own best move, not the one the first gives when it is stopped
+func TestUCIEngineRestart(t *testing.T) {
+	engine := startFakeUCIEngine(t)
+
+	var first, second chessBoard
+	first.init()
+	if err := second.loadFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	firstMoves, secondMoves := make(chan chessMove, 1), make(chan chessMove, 1)
+	engine.start(&first, nil, searchLimits{}, nil, func(move chessMove) { firstMoves <- move })
+	engine.start(&second, nil, searchLimits{depth: 3}, nil, func(move chessMove) { secondMoves <- move })
+
+	for _, search := range []struct {
+		board *chessBoard
+		moves chan chessMove
+	}{{&first, firstMoves}, {&second, secondMoves}} {
+		select {
+		case move := <-search.moves:
+			if want := search.board.getAllValidMovesForPlayer(search.board.sideToMove)[0]; move != want {
+				t.Errorf("best move from %s = %s, want %s", search.board.toFEN(), move, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no best move from %s within 5s", search.board.toFEN())
+		}
+	}
+}
+
+// The engine is given the moves of the game, not just its position, so it can know about repetitions
+func TestUCIEngineGivenGameMoves(t *testing.T) {
+	positionLog := t.TempDir() + "/positions"
+	t.Setenv(fakeUCIEngineLogEnv, positionLog)
+	engine := startFakeUCIEngine(t)
+
+	game := NewGame()
+	playMoves(t, game, "Nf3", "Nf6", "Ng1", "Ng8", "e4")
+	_, move := searchWith(t, engine, &game.board, &game.record, searchLimits{depth: 1}, 0)
+	if want := game.board.getAllValidMovesForPlayer(game.board.sideToMove)[0]; move != want {
+		t.Errorf("best move = %s, want %s", move, want)
+	}
+
+	engine.Close()
+	sent, err := os.ReadFile(positionLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "position fen " + StartingFEN + " moves g1f3 g8f6 f3g1 f6g8 e2e4\n"; string(sent) != want {
+		t.Errorf("engine was sent %q, want %q", sent, want)
+	}
+}
+
+func TestParseUCIInfo(t *testing.T) {
+	var board chessBoard
+	board.init()
+
+	tests := []struct {
+		line   string
+		ok     bool
+		score  int
+		pvSize int
+	}{
+		{"depth 10 score cp 31 nodes 100 time 5 pv e2e4 e7e5 g1f3", true, 31, 3},
+		{"depth 12 score mate 3 pv d2d4", true, mateScore - 5, 1},
+		{"depth 12 score mate -2", true, -mateScore + 4, 0},
+		{"depth 8 score cp 5 upperbound pv e2e4 e2e4", true, 5, 1}, // The pv stops at the first illegal move
+		{"currmove e2e4 currmovenumber 1", false, 0, 0},
+		{"string hello depth 3 score cp 1", false, 0, 0},
+	}
+
+	for _, test := range tests {
+		info, ok := board.parseUCIInfo(strings.Fields(test.line))
+		if ok != test.ok {
+			t.Errorf("parseUCIInfo(%q) ok = %v, want %v", test.line, ok, test.ok)
+			continue
+		}
+		if ok && (info.score != test.score || len(info.pv) != test.pvSize) {
+			t.Errorf("parseUCIInfo(%q) = score %d, pv %v, want score %d, %d pv moves", test.line, info.score, info.pv, test.score, test.pvSize)
+		}
+	}
+	if info, _ := board.parseUCIInfo(strings.Fields("depth 12 score mate -2")); info.mateIn() != -2 {
+		t.Errorf("mate -2 parsed as mate in %d", info.mateIn())
+	}
+}
@@ -0,0 +1,55 @@
+package chess
+
+import "fmt"
+
+func abs(x int) int {
+	if x < 0 {
+		return x * -1
+	}
+	return x
+}
+
+func (pc pieceColor) oppositeColor() pieceColor {
+	if pc == nocolor {
+		panic("Cannot get opposite color of nocolor")
+	}
+	if pc == white {
+		return black
+	}
+	return white
+}
+
+func (pc pieceColor) name() string {
+	switch pc {
+	case white:
+		return "white"
+	case black:
+		return "black"
+	}
+	return "nocolor"
+}
+
+func (v vector2) add(v2 vector2) vector2 {
+	return vector2{v.x + v2.x, v.y + v2.y}
+}
+
+func (v vector2) equals(v2 vector2) bool {
+	return v.x == v2.x && v.y == v2.y
+}
+
+// Returns the algebraic name of a board square, e.g. vector2{4, 3} -> "e4"
+func squareToAlgebraic(square vector2) string {
+	return fmt.Sprintf("%c%d", 'a'+square.x, square.y+1)
+}
+
+// Parses an algebraic square name such as "e4" into its board coordinates
+func algebraicToSquare(name string) (vector2, bool) {
+	if len(name) != 2 {
+		return nilSquare, false
+	}
+	square := vector2{int(name[0] - 'a'), int(name[1] - '1')}
+	if square.x < 0 || square.x > 7 || square.y < 0 || square.y > 7 {
+		return nilSquare, false
+	}
+	return square, true
+}
@@ -0,0 +1,109 @@
+package chess
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Castling rights as a bit set, in the order they are listed in FEN
+const (
+	whiteKingSideCastling = 1 << iota
+	whiteQueenSideCastling
+	blackKingSideCastling
+	blackQueenSideCastling
+)
+
+// Random keys XORed together into a position's Zobrist hash: one per piece on each square, one per set of
+// castling rights, one per en passant file and one for black to move
+var (
+	zobristPieceKeys      [3][7][64]uint64
+	zobristCastlingKeys   [16]uint64
+	zobristEnPassantKeys  [8]uint64
+	zobristBlackToMoveKey uint64
+)
+
+func init() {
+	// A fixed seed keeps hashes stable between runs, which makes them usable in logs and test expectations
+	random := rand.New(rand.NewSource(0x1eed))
+	for _, color := range []pieceColor{white, black} {
+		for pieceType := pawn; pieceType <= king; pieceType++ {
+			for index := range 64 {
+				zobristPieceKeys[color][pieceType][index] = random.Uint64()
+			}
+		}
+	}
+	for rights := range zobristCastlingKeys {
+		zobristCastlingKeys[rights] = random.Uint64()
+	}
+	for file := range zobristEnPassantKeys {
+		zobristEnPassantKeys[file] = random.Uint64()
+	}
+	zobristBlackToMoveKey = random.Uint64()
+}
+
+// The castling rights as a bit set.  A side has at most one castling rook on each side of its king, and which
+// rook it is can't change during a game, so the side is enough to tell rights apart.
+func (cb *chessBoard) castlingRights() int {
+	rights := 0
+	for _, color := range []pieceColor{white, black} {
+		rooks := cb.castlingRooks & cb.pieceBitboards[color][rook]
+		if rooks == 0 {
+			continue
+		}
+		kingFile := cb.getKingSquare(color).x
+		kingSide, queenSide := whiteKingSideCastling, whiteQueenSideCastling
+		if color == black {
+			kingSide, queenSide = blackKingSideCastling, blackQueenSideCastling
+		}
+		for rooks != 0 {
+			if indexToSquare(rooks.popFirst()).x > kingFile {
+				rights |= kingSide
+			} else {
+				rights |= queenSide
+			}
+		}
+	}
+	return rights
+}
+
+func zobristPieceKey(piece chessPiece, square vector2) uint64 {
+	if piece == emptyPiece {
+		return 0
+	}
+	return zobristPieceKeys[piece.color][piece.pieceType][squareIndex(square)]
+}
+
+// The part of the hash that doesn't come from pieces: castling rights, side to move and the en passant file.
+// The en passant file only counts when the capture is legal, so that positions with the same legal moves
+// share a hash, as the repetition rules require.
+func (cb *chessBoard) zobristStateKey() uint64 {
+	key := zobristCastlingKeys[cb.castlingRights()]
+	if cb.sideToMove == black {
+		key ^= zobristBlackToMoveKey
+	}
+	if cb.enPassantCaptureAvailable() {
+		key ^= zobristEnPassantKeys[cb.enpassantSquare.x]
+	}
+	return key
+}
+
+// Computes the Zobrist hash of the position from scratch.  movePiece and setPiece keep cb.hash up to date
+// incrementally, so this is only needed when a position is set up.
+func (cb *chessBoard) computeHash() uint64 {
+	hash := cb.zobristStateKey()
+	for x := range 8 {
+		for y := range 8 {
+			square := vector2{x, y}
+			hash ^= zobristPieceKey(cb.getPiece(square), square)
+		}
+	}
+	return hash
+}
+
+// Panics if the incrementally updated hash has drifted from the position.  Only called in builds with the
+// zobristdebug tag, as recomputing the hash on every move is slow.
+func (cb *chessBoard) verifyHash() {
+	if expected := cb.computeHash(); cb.hash != expected {
+		panic(fmt.Sprintf("zobrist hash %016x does not match %016x recomputed for %s", cb.hash, expected, cb.toFEN()))
+	}
+}
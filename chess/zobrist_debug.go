@@ -0,0 +1,6 @@
+//go:build zobristdebug
+
+package chess
+
+// Built with -tags zobristdebug: every move checks the incremental hash against a full recomputation
+const zobristDebug = true
@@ -0,0 +1,5 @@
+//go:build !zobristdebug
+
+package chess
+
+const zobristDebug = false
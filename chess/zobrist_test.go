@@ -0,0 +1,40 @@
+package chess
+
+import "testing"
+
+// The hash kept up to date by movePiece and unmakeMove matches one computed from scratch, through captures,
+// en passant, castling on both sides and promotions
+func TestIncrementalHash(t *testing.T) {
+	var board chessBoard
+	if err := board.loadFEN("r3k2r/1P6/8/3pP3/8/8/8/R3K2R w KQkq d6 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if board.hash != board.computeHash() {
+		t.Fatalf("hash %016x of the loaded position, want %016x", board.hash, board.computeHash())
+	}
+	startHash := board.hash
+
+	var undos []moveUndoState
+	var hashes []uint64
+	for _, san := range []string{"exd6", "O-O", "bxa8=Q", "Rxa8", "O-O-O", "Ra2", "d7", "Kg7", "d8=N", "Ra1+"} {
+		move, err := board.sanToMove(san)
+		if err != nil {
+			t.Fatalf("%s in %s: %v", san, board.toFEN(), err)
+		}
+		hashes = append(hashes, board.hash)
+		undos = append(undos, board.movePiece(move))
+		if want := board.computeHash(); board.hash != want {
+			t.Errorf("hash %016x after %s, want %016x for %s", board.hash, san, want, board.toFEN())
+		}
+	}
+
+	for i := len(undos) - 1; i >= 0; i-- {
+		board.unmakeMove(undos[i])
+		if want := board.computeHash(); board.hash != want || board.hash != hashes[i] {
+			t.Errorf("hash %016x after undoing move %d, want %016x for %s", board.hash, i+1, hashes[i], board.toFEN())
+		}
+	}
+	if board.hash != startHash {
+		t.Errorf("hash %016x after undoing every move, want %016x", board.hash, startHash)
+	}
+}
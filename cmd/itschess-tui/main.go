@@ -0,0 +1,58 @@
+// Plays It's Chess in the terminal, for machines without a display
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/benwheeler12/itschess/internal/tui"
+)
+
+func main() {
+	fen := flag.String("fen", "", "start from the position given in Forsyth-Edwards Notation")
+	pgn := flag.String("pgn", "", "continue the first game of the given PGN file")
+	chess960 := flag.String("chess960", "", "play Chess960 from the starting position with this number, 0 to 959, or random")
+	plain := flag.Bool("plain", false, "draw the board in plain ASCII without colours")
+	flip := flag.Bool("flip", false, "draw the board with black at the bottom")
+	engineColor := flag.String("engine", "", "let the engine play white, black or both")
+	engineDepth := flag.Int("depth", 0, "maximum engine search depth in plies (default no limit)")
+	engineMoveTime := flag.Duration("movetime", 0, "engine thinking time per move (default 1s if -depth is not set)")
+	engineWeights := flag.String("weights", "", "JSON file of evaluation weights for the engine")
+	enginePath := flag.String("uci", "", "play with the UCI engine program at this path instead of the built-in engine")
+	flag.Parse()
+
+	game := chess.NewGame()
+	var err error
+	switch {
+	case *pgn != "":
+		var text []byte
+		if text, err = os.ReadFile(*pgn); err == nil {
+			game, err = chess.NewGameFromPGN(string(text))
+		}
+	case *fen != "":
+		game, err = chess.NewGameFromFEN(*fen)
+	case *chess960 != "":
+		var position int
+		if position, err = chess.ParseChess960Position(*chess960); err == nil {
+			game, err = chess.NewChess960Game(position)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	options := tui.Options{
+		Color:          !*plain && os.Getenv("NO_COLOR") == "",
+		Flip:           *flip,
+		EngineColor:    *engineColor,
+		EngineDepth:    *engineDepth,
+		EngineMoveTime: *engineMoveTime,
+		EngineWeights:  *engineWeights,
+		EnginePath:     *enginePath,
+	}
+	if err := tui.Run(game, os.Stdin, os.Stdout, options); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+// Runs the It's Chess engine as a Universal Chess Interface engine over stdin and stdout, for use in chess
+// GUIs and tournament managers
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+func main() {
+	if err := chess.RunUCI(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,7 +1,69 @@
 package main
 
-import chessgame "github.com/benwheeler12/itschess/internal/itschess"
+import (
+	"flag"
+	"log"
+
+	"github.com/benwheeler12/itschess/chess"
+	chessgame "github.com/benwheeler12/itschess/internal/itschess"
+)
 
 func main() {
-	chessgame.StartGame()
+	fen := flag.String("fen", "", "start from the position given in Forsyth-Edwards Notation")
+	pgn := flag.String("pgn", "", "continue the first game of the given PGN file")
+	chess960 := flag.String("chess960", "", "play Chess960 from the starting position with this number, 0 to 959, or random")
+	engineColor := flag.String("engine", "", "let the engine play white, black or both")
+	engineDepth := flag.Int("depth", 0, "maximum engine search depth in plies (default no limit)")
+	engineMoveTime := flag.Duration("movetime", 0, "engine thinking time per move (default 1s if -depth is not set)")
+	engineWeights := flag.String("weights", "", "JSON file of evaluation weights for the engine")
+	enginePath := flag.String("uci", "", "play or analyze with the UCI engine program at this path instead of the built-in engine")
+	analyze := flag.Bool("analyze", false, "show the engine's analysis of the positions where the player is to move")
+	host := flag.String("host", "", "serve the game on this address, such as :7878, for another player to join")
+	join := flag.String("join", "", "join the game served at this address, such as example.com:7878")
+	networkColor := flag.String("color", "", "side to play in a networked game, white or black (default whichever is free)")
+	timeControl := flag.String("clock", "", "play with clocks: minutes per game, e.g. 5, then +seconds of increment, dseconds of simple delay or bseconds of Bronstein delay, with periods such as 40/90+30,30+30")
+	book := flag.String("book", "", "Polyglot opening book (.bin) to show the moves of and for the engine to play from")
+	bestBookMove := flag.Bool("bookbest", false, "have the engine play the book's most weighted move instead of picking one by weight")
+	flip := flag.Bool("flip", false, "put black's side of the board at the bottom")
+	autoFlip := flag.Bool("autoflip", false, "turn the board to the side to move after every move, for players sharing a screen")
+	setup := flag.Bool("setup", false, "set up a position to play from, starting from the position of -fen, -pgn or -chess960 if given")
+	syzygy := flag.String("syzygy", "", "directories of Syzygy tablebase files to annotate endgames with and for the engine to play from")
+	flag.Parse()
+
+	options := chessgame.Options{
+		EngineColor:    *engineColor,
+		EngineDepth:    *engineDepth,
+		EngineMoveTime: *engineMoveTime,
+		EngineWeights:  *engineWeights,
+		EnginePath:     *enginePath,
+		Analyze:        *analyze,
+		Host:           *host,
+		Join:           *join,
+		NetworkColor:   *networkColor,
+		TimeControl:    *timeControl,
+		Book:           *book,
+		BestBookMove:   *bestBookMove,
+		Setup:          *setup,
+		Flip:           *flip,
+		AutoFlip:       *autoFlip,
+		Syzygy:         *syzygy,
+	}
+
+	var err error
+	switch {
+	case *pgn != "":
+		err = chessgame.StartGameFromPGN(*pgn, options)
+	case *fen != "":
+		err = chessgame.StartGameFromFEN(*fen, options)
+	case *chess960 != "":
+		var position int
+		if position, err = chess.ParseChess960Position(*chess960); err == nil {
+			err = chessgame.StartChess960Game(position, options)
+		}
+	default:
+		err = chessgame.StartGame(options)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -5,10 +5,10 @@ go 1.24.0
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.8.6
 	github.com/srwiley/rasterx v0.0.0-20210519020934-456a8d69b780
+	golang.org/x/image v0.20.0
 )
 
 require (
-	golang.org/x/image v0.20.0 // indirect
 	golang.org/x/net v0.0.0-20211118161319-6a13c67c3ce4 // indirect
 	golang.org/x/text v0.18.0 // indirect
 )
@@ -2,13 +2,13 @@ package itschess
 
 import (
 	"bytes"
-	"fmt"
 	"image"
 	"image/color"
 	"log"
 	"math"
 	"math/rand"
 
+	"github.com/benwheeler12/itschess/chess"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
 	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
@@ -21,24 +21,29 @@ import (
 
 type chessBoardGraphic struct {
 	// Graphics Properties
-	origin        point
-	rotationTheta float64
-	reflection    int
-	pieceImages   map[chessPiece]*ebiten.Image
-	width         int
-	height        int
+	origin         point
+	flipped        bool // Whether black's side of the board is at the bottom
+	pieceImages    map[chess.Piece]*ebiten.Image
+	width          int
+	height         int
+	sidePanelWidth int // Room kept right of the board for the move list and clocks
 	// Game Properties
 	clickedSquare          vector2
 	clickedPromotionSquare vector2
-	possibleMoveSquares    []vector2
+	possibleMoves          []chess.Move
+	bookMoves              []chess.BookMove
 	promotionSquare        vector2
 	confetti               []confetti
 }
 
 const pieceScale = .9
 
+// Width of the panel beside the board that lists the moves and shows the clocks
+const sidePanelWidth = 200
+
 var (
 	mplusNormalFont font.Face
+	mplusSmallFont  font.Face
 )
 
 func init() {
@@ -56,22 +61,54 @@ func init() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	mplusSmallFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    16,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func (cbg *chessBoardGraphic) init(origin point, rotationTheta float64, reflection int, width int, height int) {
-	cbg.rotationTheta = rotationTheta
-	cbg.reflection = reflection
+func (cbg *chessBoardGraphic) init(origin point, flipped bool, width int, height int) {
+	cbg.origin = origin
+	cbg.flipped = flipped
 	cbg.width = width
 	cbg.height = height
 	cbg.loadPieceImages()
 
 	cbg.clickedSquare = nilSquare
 	cbg.clickedPromotionSquare = nilSquare
-	cbg.possibleMoveSquares = nil
+	cbg.possibleMoves = nil
 	cbg.promotionSquare = nilSquare
 }
 
-func (cbg *chessBoardGraphic) drawChessBoard(chessBoard *chessBoard) *ebiten.Image {
+// The pieces on the board: those of a game, or of a position being set up
+type piecePlacement interface {
+	Piece(square chess.Square) chess.Piece
+}
+
+// The game's outcome decides which end of game screen, if any, is drawn over the board
+func (cbg *chessBoardGraphic) drawChessBoard(game *chess.Game) *ebiten.Image {
+	chessBoardImage := cbg.drawPosition(game)
+
+	switch outcome := game.Outcome(); outcome.Result() {
+	case chess.WhiteWon, chess.BlackWon:
+		cbg.drawCheckmateAnimation(chessBoardImage, outcome)
+	case chess.Drawn:
+		cbg.drawDrawScreen(chessBoardImage, outcome.String())
+	}
+
+	x, y := ebiten.CursorPosition()
+	mousePosition := vector2{x, y}
+	cbg.drawClickedPiece(game, mousePosition, chessBoardImage)
+
+	return chessBoardImage
+}
+
+// Draws the squares and the pieces on them, turned to face the player
+func (cbg *chessBoardGraphic) drawPosition(position piecePlacement) *ebiten.Image {
 	chessBoardImage := ebiten.NewImage(cbg.boardWidth(), cbg.boardHeight())
 
 	chessBoardImage.Fill(color.RGBA{255, 255, 255, 255})
@@ -83,11 +120,13 @@ func (cbg *chessBoardGraphic) drawChessBoard(chessBoard *chessBoard) *ebiten.Ima
 			squareX := cbg.squareWidth() * float64(file)
 			squareY := cbg.squareHeight() * float64(rank)
 
-			cbg.drawChessSquare(chessBoardImage, chessBoard, squareX, squareY, vector2{file, rank})
+			cbg.drawChessSquare(chessBoardImage, position, squareX, squareY, vector2{file, rank})
 			continue
 		}
 	}
 
+	cbg.drawBookMoves(chessBoardImage)
+
 	if cbg.promotionSquare != nilSquare {
 		cbg.drawPromotionBox(chessBoardImage)
 	}
@@ -98,62 +137,58 @@ func (cbg *chessBoardGraphic) drawChessBoard(chessBoard *chessBoard) *ebiten.Ima
 	rotatedImage := ebiten.NewImage(cbg.boardWidth(), cbg.boardHeight())
 	rotatedImage.DrawImage(chessBoardImage, op)
 
-	chessBoardImage = rotatedImage
-
-	// TODO implement checkmate animation
-	if chessBoard.playerInCheckMate(white) || chessBoard.playerInCheckMate(black) {
-		// Draw checkmate animation
-		cbg.drawCheckmateAnimation(chessBoardImage, chessBoard)
-	}
-
-	x, y := ebiten.CursorPosition()
-	mousePosition := vector2{x, y}
-	cbg.drawClickedPiece(chessBoard, mousePosition, rotatedImage)
-
 	return rotatedImage
 }
 
-func (cbg *chessBoardGraphic) drawClickedPiece(cb *chessBoard, mousePosition vector2, screen *ebiten.Image) {
+func (cbg *chessBoardGraphic) drawClickedPiece(game *chess.Game, mousePosition vector2, screen *ebiten.Image) {
 	// Draw the clicked piece
 	if cbg.clickedSquare != nilSquare {
-		op := &ebiten.DrawImageOptions{}
-		//op.GeoM = cbg.getPieceRotationGeo()
-		op.GeoM.Translate(-cbg.pieceWidth()/2, -cbg.pieceHeight()/2)
-		op.GeoM.Scale(-1, 1)
-		op.GeoM.Translate(float64(mousePosition.x), float64(mousePosition.y))
-		screen.DrawImage(cbg.pieceImages[cb.getPiece(cbg.clickedSquare)], op)
+		cbg.drawPieceAt(screen, game.Piece(boardSquare(cbg.clickedSquare)), float64(mousePosition.x), float64(mousePosition.y), 1)
 	}
 }
 
+// Draws a piece centered on a point of the screen, scaled from its size on the board
+func (cbg *chessBoardGraphic) drawPieceAt(screen *ebiten.Image, piece chess.Piece, x float64, y float64, scale float64) {
+	image, ok := cbg.pieceImages[piece]
+	if !ok {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-cbg.pieceWidth()/2, -cbg.pieceHeight()/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(image, op)
+}
+
 // Loads Piece Images based on width and height of graphic
 func (cbg *chessBoardGraphic) loadPieceImages() {
-	cbg.pieceImages = map[chessPiece]*ebiten.Image{
-		{pawn, white}:   cbg.getPieceImage(whitePawnBytes),
-		{pawn, black}:   cbg.getPieceImage(blackPawnBytes),
-		{bishop, white}: cbg.getPieceImage(whiteBishopBytes),
-		{bishop, black}: cbg.getPieceImage(blackBishopBytes),
-		{knight, white}: cbg.getPieceImage(whiteKnightBytes),
-		{knight, black}: cbg.getPieceImage(blackKnightBytes),
-		{rook, white}:   cbg.getPieceImage(whiteRookBytes),
-		{rook, black}:   cbg.getPieceImage(blackRookBytes),
-		{queen, white}:  cbg.getPieceImage(whiteQueenBytes),
-		{queen, black}:  cbg.getPieceImage(blackQueenBytes),
-		{king, white}:   cbg.getPieceImage(whiteKingBytes),
-		{king, black}:   cbg.getPieceImage(blackKingBytes),
+	cbg.pieceImages = map[chess.Piece]*ebiten.Image{
+		{Type: chess.Pawn, Color: chess.White}:   cbg.getPieceImage(whitePawnBytes),
+		{Type: chess.Pawn, Color: chess.Black}:   cbg.getPieceImage(blackPawnBytes),
+		{Type: chess.Bishop, Color: chess.White}: cbg.getPieceImage(whiteBishopBytes),
+		{Type: chess.Bishop, Color: chess.Black}: cbg.getPieceImage(blackBishopBytes),
+		{Type: chess.Knight, Color: chess.White}: cbg.getPieceImage(whiteKnightBytes),
+		{Type: chess.Knight, Color: chess.Black}: cbg.getPieceImage(blackKnightBytes),
+		{Type: chess.Rook, Color: chess.White}:   cbg.getPieceImage(whiteRookBytes),
+		{Type: chess.Rook, Color: chess.Black}:   cbg.getPieceImage(blackRookBytes),
+		{Type: chess.Queen, Color: chess.White}:  cbg.getPieceImage(whiteQueenBytes),
+		{Type: chess.Queen, Color: chess.Black}:  cbg.getPieceImage(blackQueenBytes),
+		{Type: chess.King, Color: chess.White}:   cbg.getPieceImage(whiteKingBytes),
+		{Type: chess.King, Color: chess.Black}:   cbg.getPieceImage(blackKingBytes),
 	}
 }
 
-func (cbg *chessBoardGraphic) getPromotionPiece(promotionSquare vector2) piece {
+func (cbg *chessBoardGraphic) getPromotionPiece(promotionSquare vector2) chess.PieceType {
 
 	switch promotionSquare {
 	case vector2{1, 0}:
-		return knight
+		return chess.Knight
 	case vector2{0, 0}:
-		return rook
+		return chess.Rook
 	case vector2{1, 1}:
-		return bishop
+		return chess.Bishop
 	case vector2{0, 1}:
-		return queen
+		return chess.Queen
 	default:
 		panic("Invalid promotion square")
 	}
@@ -193,11 +228,11 @@ func (cbg *chessBoardGraphic) getPieceImage(pieceBytes []byte) *ebiten.Image {
 
 // Methods for basic dimensions as functions of window height and width
 func (cbg *chessBoardGraphic) boardWidth() int {
-	return min(cbg.width, cbg.height) + 4
+	return min(cbg.width-cbg.sidePanelWidth, cbg.height) + 4
 }
 
 func (cbg *chessBoardGraphic) boardHeight() int {
-	return min(cbg.width, cbg.height) + 4
+	return min(cbg.width-cbg.sidePanelWidth, cbg.height) + 4
 }
 
 func (cbg *chessBoardGraphic) squareWidth() float64 {
@@ -227,16 +262,15 @@ func (cbg *chessBoardGraphic) positionInGraphic(mousePosition vector2) bool {
 
 func (cbg *chessBoardGraphic) getSquareOfMousePosition(mousePosition vector2) vector2 {
 
+	// Undo turning the board to find where the mouse is on the board image as it was drawn
 	geo := cbg.getBoardRotationGeo()
+	geo.Invert()
 
 	x, y := geo.Apply(float64(mousePosition.x), float64(mousePosition.y))
 
-	// Create a point for the coordinate
+	file := int(x / cbg.squareWidth())
+	rank := int(y / cbg.squareHeight())
 
-	file := int((x - cbg.origin.x) / cbg.squareWidth())
-	rank := int((y - cbg.origin.y) / cbg.squareHeight())
-
-	// Apply rotation based on rotationFactor
 	return vector2{file, rank}
 }
 
@@ -255,6 +289,9 @@ func (cbg *chessBoardGraphic) getElementAtMousePosition(mousePosition vector2) c
 	}
 }
 
+// The center of the promotion popup for a pawn promoting on promotionSquare, on the board image before it is
+// turned.  The popup covers the square and its neighbours towards the middle of the board, so it stays on the
+// board whichever side is at the bottom.
 func (cbg *chessBoardGraphic) getPromotionPopupOrigin(promotionSquare vector2) point {
 
 	// Get top corner of square
@@ -263,11 +300,11 @@ func (cbg *chessBoardGraphic) getPromotionPopupOrigin(promotionSquare vector2) p
 	// Translate into center of square from corner
 	popUpOrigin = popUpOrigin.add(point{cbg.squareWidth() / 2, cbg.squareHeight() / 2})
 
-	// Translate vertically away from edge of board
+	// Translate away from the rank of the board the pawn promotes on
 	if promotionSquare.y == 0 {
 		// Move so popup is at edge of board
 		popUpOrigin = popUpOrigin.add(point{0, cbg.squareHeight()})
-	} else if promotionSquare.y == 7 { // Top of board case
+	} else if promotionSquare.y == 7 {
 		// Move so popup is at edge of board
 		popUpOrigin = popUpOrigin.add(point{0, -cbg.squareHeight()})
 	} else {
@@ -279,14 +316,14 @@ func (cbg *chessBoardGraphic) getPromotionPopupOrigin(promotionSquare vector2) p
 		popUpOrigin.x += cbg.squareWidth()
 	}
 	if promotionSquare.x == 7 {
-		popUpOrigin.x -= cbg.squareHeight()
+		popUpOrigin.x -= cbg.squareWidth()
 	}
 
 	return popUpOrigin
 }
 
 // square refers to the canonical chess square that this function call will draw
-func (cbg *chessBoardGraphic) drawChessSquare(screen *ebiten.Image, chessBoard *chessBoard, x float64, y float64, square vector2) {
+func (cbg *chessBoardGraphic) drawChessSquare(screen *ebiten.Image, position piecePlacement, x float64, y float64, square vector2) {
 
 	// Calculate Color
 	squareColor := lightSquareColor
@@ -295,7 +332,7 @@ func (cbg *chessBoardGraphic) drawChessSquare(screen *ebiten.Image, chessBoard *
 		squareColor = darkSquareColor
 	}
 
-	if contains(cbg.possibleMoveSquares, square) {
+	if cbg.isPossibleMoveSquare(square) {
 		squareColor = tintColor(squareColor, color.RGBA{255, 255, 0, 1}, 0.3)
 	}
 
@@ -308,11 +345,20 @@ func (cbg *chessBoardGraphic) drawChessSquare(screen *ebiten.Image, chessBoard *
 		true)
 
 	if cbg.clickedSquare != square {
-		cbg.drawChessPiece(chessBoard.getPiece(square), x, y, screen)
+		cbg.drawChessPiece(position.Piece(boardSquare(square)), x, y, screen)
 	}
 
 }
 
+func (cbg *chessBoardGraphic) isPossibleMoveSquare(square vector2) bool {
+	for _, move := range cbg.possibleMoves {
+		if move.To == boardSquare(square) {
+			return true
+		}
+	}
+	return false
+}
+
 // Draws a white Box with width and height equal to twice the width and height of a chess square.
 // The box has black borders that are rounded at the corner, and has a vertical and horizontal line running through its center in a symmetrical cross
 // The box is drawn to the screen with its center at the coordinates specified by boxCenter
@@ -327,11 +373,11 @@ func (cbg *chessBoardGraphic) drawPromotionBox(screen *ebiten.Image) {
 	x := boxCenter.x - float64(boxWidth)/2
 	y := boxCenter.y - float64(boxHeight)/2
 
-	var pieceColor pieceColor
+	var pieceColor chess.Color
 	if cbg.promotionSquare.y == 7 {
-		pieceColor = white
+		pieceColor = chess.White
 	} else {
-		pieceColor = black
+		pieceColor = chess.Black
 	}
 
 	// Draw white box
@@ -348,11 +394,11 @@ func (cbg *chessBoardGraphic) drawPromotionBox(screen *ebiten.Image) {
 	vector.StrokeLine(screen, float32(x), float32(y+float64(boxHeight)/2), float32(x+float64(boxWidth)), float32(y+float64(boxHeight)/2), borderWidth, color.RGBA{0, 0, 0, 255}, true)
 
 	// Define the pieces to be drawn
-	pieces := []chessPiece{
-		{pieceType: rook, color: pieceColor},
-		{pieceType: queen, color: pieceColor},
-		{pieceType: knight, color: pieceColor},
-		{pieceType: bishop, color: pieceColor},
+	pieces := []chess.Piece{
+		{Type: chess.Rook, Color: pieceColor},
+		{Type: chess.Queen, Color: pieceColor},
+		{Type: chess.Knight, Color: pieceColor},
+		{Type: chess.Bishop, Color: pieceColor},
 	}
 
 	// Calculate the positions for each piece within the promotion box
@@ -366,6 +412,7 @@ func (cbg *chessBoardGraphic) drawPromotionBox(screen *ebiten.Image) {
 func (cbg *chessBoardGraphic) getPromotionSquareOfMousePosition(mousePosition vector2) vector2 {
 
 	geo := cbg.getBoardRotationGeo()
+	geo.Invert()
 
 	mouseX, mouseY := geo.Apply(float64(mousePosition.x), float64(mousePosition.y))
 
@@ -408,7 +455,7 @@ func (cbg *chessBoardGraphic) Layout(outsideWidth, outsideHeight int) (int, int)
 	return outsideWidth, outsideHeight
 }
 
-func (cbg *chessBoardGraphic) drawChessPiece(piece chessPiece, x float64, y float64, screen *ebiten.Image) {
+func (cbg *chessBoardGraphic) drawChessPiece(piece chess.Piece, x float64, y float64, screen *ebiten.Image) {
 
 	image, ok := cbg.pieceImages[piece]
 	if !ok {
@@ -432,21 +479,15 @@ func (cbg *chessBoardGraphic) drawChessPiece(piece chessPiece, x float64, y floa
 	screen.DrawImage(image, op)
 }
 
+// Turns the board image, drawn with a1 in the top left corner, to face the player
 func (cbg *chessBoardGraphic) getBoardRotationGeo() ebiten.GeoM {
 
 	geom := ebiten.GeoM{}
 
 	w, h := float64(cbg.boardWidth()), float64(cbg.boardHeight())
 	geom.Translate(-w/2, -h/2)
-	_, _ = w, h
-
-	// Rotate 180 degrees (π radians)
-	geom.Rotate(cbg.rotationTheta)
 
-	// Scale by -1 in the x direction to flip horizontally
-	if cbg.reflection == 1 {
-		geom.Scale(-1, 1)
-	}
+	geom.Scale(cbg.boardReflection())
 
 	// Move back to screen coordinates
 	geom.Translate(w/2, h/2)
@@ -457,11 +498,21 @@ func (cbg *chessBoardGraphic) getBoardRotationGeo() ebiten.GeoM {
 	return geom
 }
 
+// How the board image is mirrored to face the player: top to bottom to put white's side at the bottom, or left
+// to right to put black's side there
+func (cbg *chessBoardGraphic) boardReflection() (float64, float64) {
+	if cbg.flipped {
+		return -1, 1
+	}
+	return 1, -1
+}
+
+// Mirrors a piece the same way as the board, so that it is the right way round once the board is turned
 func (cbg *chessBoardGraphic) getPieceRotationGeo() ebiten.GeoM {
 	geom := ebiten.GeoM{}
 
 	geom.Translate(-cbg.pieceWidth()/2, -cbg.pieceHeight()/2)
-	geom.Rotate(cbg.rotationTheta)
+	geom.Scale(cbg.boardReflection())
 	geom.Translate(cbg.pieceWidth()/2, cbg.pieceHeight()/2)
 
 	return geom
@@ -476,7 +527,7 @@ type confetti struct {
 	size      float64
 }
 
-func (cbg *chessBoardGraphic) drawCheckmateAnimation(screen *ebiten.Image, chessBoard *chessBoard) {
+func (cbg *chessBoardGraphic) drawCheckmateAnimation(screen *ebiten.Image, outcome chess.Outcome) {
 	// Create confetti particles if not already created
 	if cbg.confetti == nil {
 		cbg.confetti = make([]confetti, 100)
@@ -521,14 +572,18 @@ func (cbg *chessBoardGraphic) drawCheckmateAnimation(screen *ebiten.Image, chess
 		screen.DrawImage(confettiImg, op)
 	}
 
-	// Determine winner and create text
-	winner := "Black"
-	if chessBoard.playerInCheckMate(black) {
-		winner = "White"
-	}
+	cbg.drawEndGameBanner(screen, outcome.String(), color.RGBA{255, 215, 0, 255}) // Gold color
+}
+
+// Counterpart to drawCheckmateAnimation for drawn games: greys out the board and announces the draw
+func (cbg *chessBoardGraphic) drawDrawScreen(screen *ebiten.Image, msg string) {
+	vector.DrawFilledRect(screen, 0, 0, float32(cbg.boardWidth()), float32(cbg.boardHeight()), color.RGBA{128, 128, 128, 120}, true)
+
+	cbg.drawEndGameBanner(screen, msg, color.RGBA{192, 192, 192, 255}) // Silver color
+}
 
-	// Draw text
-	textColor := color.RGBA{255, 215, 0, 255} // Gold color
+// Draws msg in a bordered box at the center of the board
+func (cbg *chessBoardGraphic) drawEndGameBanner(screen *ebiten.Image, msg string, textColor color.RGBA) {
 	textX := float64(cbg.boardWidth()) / 2
 	textY := float64(cbg.boardHeight()) / 2
 
@@ -536,6 +591,7 @@ func (cbg *chessBoardGraphic) drawCheckmateAnimation(screen *ebiten.Image, chess
 	vector.DrawFilledRect(screen, float32(textX-198), float32(textY-28), 396, 56, color.RGBA{255, 255, 255, 180}, true)
 	vector.DrawFilledRect(screen, float32(textX-196), float32(textY-26), 392, 52, color.RGBA{0, 0, 0, 180}, true)
 
-	msg := fmt.Sprintf("%s Won the Game!", winner)
-	ebitentext.Draw(screen, msg, mplusNormalFont, int(textX-100), int(textY+10), textColor)
+	// Center the message horizontally
+	msgWidth := font.MeasureString(mplusNormalFont, msg).Ceil()
+	ebitentext.Draw(screen, msg, mplusNormalFont, int(textX)-msgWidth/2, int(textY+10), textColor)
 }
@@ -2,27 +2,131 @@ package itschess
 
 import (
 	"image/color"
+	"log"
 
+	"github.com/benwheeler12/itschess/chess"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 
 	_ "embed"
 )
 
 type ChessGame struct {
-	chessBoard
+	game *chess.Game
 	chessBoardGraphic
 	mouseState
 	mouseLifeCycle
 	promotionLifeCycle
-	whitesTurn bool
+	opponent engineOpponent
+	network  networkOpponent
+	clock    *chess.Clock       // nil for untimed games
+	book     *chess.OpeningBook // nil without an opening book
+	bookFEN  string             // The position the book moves on the board were looked up for
+	moveList moveList
+	setup    *positionSetup // The position being set up, nil while playing
+	autoFlip bool           // Whether the board turns to put the side to move at the bottom
+
+	tablebase           *chess.Tablebase // nil without a tablebase
+	tablebaseFEN        string           // The position the tablebase annotation is for
+	tablebaseAnnotation string           // The tablebase's result for the position, "" if it doesn't cover it
 }
 
 func (g *ChessGame) Update() error {
+	g.updateOrientation()
 
-	if g.chessBoard.playerInCheckMate(white) || g.chessBoard.playerInCheckMate(black) {
+	if g.setup != nil {
+		g.updateSetup()
+		return nil
+	}
+
+	// Undo with Ctrl+Z, redo with Ctrl+Y or Ctrl+Shift+Z.  Handled before the game over check so a mate can be taken back.
+	// Moves played over the network or against the clock can't be taken back.
+	if (ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)) && !g.playingNetwork() && g.clock == nil {
+		shiftPressed := ebiten.IsKeyPressed(ebiten.KeyShift)
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) && !shiftPressed {
+			g.undoMove()
+			// Against the engine, take back its reply as well so that it is the player's turn again
+			if g.playingEngine() && g.engineToMove() {
+				g.undoMove()
+			}
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyY) || (inpututil.IsKeyJustPressed(ebiten.KeyZ) && shiftPressed) {
+			g.redoMove()
+			if g.playingEngine() && g.engineToMove() {
+				g.redoMove()
+			}
+		}
+	}
+
+	// Log the current position so it can be shared or reloaded with -fen
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		log.Printf("FEN: %s", g.game.FEN())
+	}
+
+	// Log the game so far in PGN
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		if pgn, err := g.game.PGN(); err != nil {
+			log.Print(err)
+		} else {
+			log.Printf("PGN:\n%s", pgn)
+		}
+	}
+
+	// Set up a new position, starting from the one on the board.  Like taking back moves, not over the network or
+	// against the clock.
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) && !g.playingNetwork() && g.clock == nil {
+		g.startSetup(g.displayedGame().Setup())
+		return nil
+	}
+
+	if g.playingNetwork() {
+		g.updateNetwork()
+	}
+
+	if g.clock != nil && !g.game.Outcome().IsOver() {
+		g.updateClock()
+	}
+
+	g.updateMoveList()
+
+	if g.book != nil {
+		g.updateBookMoves()
+	}
+
+	if g.tablebase != nil {
+		g.updateTablebase()
+	}
+
+	if g.game.Outcome().IsOver() {
 		return nil // Game over baby
 	}
 
+	if g.engineToMove() {
+		g.updateEngine()
+		return nil // The player's input waits for the engine's move
+	}
+	if g.opponent.analyze {
+		g.updateAnalysis()
+	}
+
+	// Claim a draw by threefold repetition or the fifty-move rule
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		g.claimDraw()
+	}
+
+	// Draw by agreement
+	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		g.agreeDraw()
+	}
+
+	// The side to move resigns, or the player in a networked game
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.resign()
+	}
+
+	if g.networkOpponentToMove() {
+		return nil // The player's input waits for the opponent's move
+	}
+
 	g.lastMouseState = g.mousePressed
 
 	g.mousePressed = ebiten.IsMouseButtonPressed((ebiten.MouseButtonLeft))
@@ -39,8 +143,12 @@ func (g *ChessGame) Update() error {
 }
 
 func (g *ChessGame) Draw(screen *ebiten.Image) {
+	if g.setup != nil {
+		g.drawSetup(screen)
+		return
+	}
 
-	chessBoardImage := g.chessBoardGraphic.drawChessBoard(&g.chessBoard)
+	chessBoardImage := g.chessBoardGraphic.drawChessBoard(g.displayedGame())
 
 	op := &ebiten.DrawImageOptions{}
 
@@ -48,6 +156,14 @@ func (g *ChessGame) Draw(screen *ebiten.Image) {
 
 	screen.DrawImage(chessBoardImage, op)
 
+	g.drawMoveList(screen)
+	g.drawAnalysis(screen)
+	g.drawOrientationButtons(screen)
+
+	if g.clock != nil {
+		g.drawClocks(screen)
+	}
+	g.drawTablebase(screen)
 }
 
 func (g *ChessGame) handleMouseClick() {
@@ -59,29 +175,28 @@ func (g *ChessGame) handleMouseClick() {
 		return
 	}
 
+	// Earlier positions are only there to be looked at
+	if g.moveList.view != nil {
+		return
+	}
+
 	clickedElement := g.chessBoardGraphic.getElementAtMousePosition(mousePosition)
 
 	if clickedElement.isChessSquare {
 		mouseSquare := clickedElement.square
-		// Handle click on empty square
-		if g.chessBoard.isEmpty(mouseSquare) {
-			return
-		}
-
-		// Handle Click on Actual Piece
-		clickedPiece := g.chessBoard.getPiece(mouseSquare)
 
-		// If it is not the turn of the piece color that was clicked, do nothing
-		if (g.whitesTurn && clickedPiece.color == black) ||
-			(!g.whitesTurn && clickedPiece.color == white) {
+		// Only the pieces of the side to move can be picked up
+		if g.game.Piece(boardSquare(mouseSquare)).Color != g.game.Turn() {
 			return
 		}
-		// Else, load the mouseLifeCycle State with info of clicked piece
+		// Load the mouseLifeCycle State with info of clicked piece
 		g.chessBoardGraphic.clickedSquare = mouseSquare
-		g.chessBoardGraphic.possibleMoveSquares = g.chessBoard.getValidMoves(g.chessBoardGraphic.clickedSquare)
+		g.chessBoardGraphic.possibleMoves = g.game.LegalMovesFrom(boardSquare(mouseSquare))
 	} else if clickedElement.isPromotionSquare {
 		clickedSquare := clickedElement.square
+		// Clicking outside the promotion box cancels the promotion
 		if clickedSquare == nilSquare {
+			g.cancelPromotion()
 			return
 		}
 		g.chessBoardGraphic.clickedPromotionSquare = clickedElement.square
@@ -92,7 +207,7 @@ func (g *ChessGame) handleMouseClick() {
 func (g *ChessGame) handleMouseRelease() {
 	// Reset Chessboard Graphic State
 	defer func() {
-		g.chessBoardGraphic.possibleMoveSquares = nil
+		g.chessBoardGraphic.possibleMoves = nil
 		g.chessBoardGraphic.clickedSquare = nilSquare
 		g.chessBoardGraphic.clickedPromotionSquare = nilSquare
 	}()
@@ -109,36 +224,171 @@ func (g *ChessGame) handleMouseRelease() {
 	if clickedElement.isChessSquare {
 		mouseSquare := clickedElement.square
 
-		// Move is invalid
-		if !contains(g.chessBoardGraphic.possibleMoveSquares, mouseSquare) {
+		// Collect the moves to the released square, one per promotion piece for promotions
+		var selectedMoves []chess.Move
+		for _, move := range g.chessBoardGraphic.possibleMoves {
+			if move.To == boardSquare(mouseSquare) {
+				selectedMoves = append(selectedMoves, move)
+			}
+		}
+
+		// Castling is taken in either form, the king dropped on its own rook or on the square it castles to
+		if len(selectedMoves) == 0 {
+			move := chess.Move{From: boardSquare(g.chessBoardGraphic.clickedSquare), To: boardSquare(mouseSquare)}
+			if g.game.IsLegal(move) {
+				g.playMove(move)
+			}
 			return
 		}
 
-		// Move the piece
-		g.chessBoard.movePiece(g.chessBoardGraphic.clickedSquare, mouseSquare)
-		if g.chessBoard.promotionTriggeredOnSquare(mouseSquare) {
+		if selectedMoves[0].Promotion != 0 {
+			// The move is played once the promotion piece is chosen
 			g.chessBoardGraphic.promotionSquare = mouseSquare
-		} else {
-			g.whitesTurn = !g.whitesTurn
+			g.promotionLifeCycle.promotionMoves = selectedMoves
+			g.promotionLifeCycle.promotionInProgress = true
+			return
 		}
+
+		g.playMove(selectedMoves[0])
 	} else if clickedElement.isPromotionSquare {
 		clickedSquare := clickedElement.square
 		// Perform Promotion
-		if clickedSquare == g.chessBoardGraphic.clickedPromotionSquare {
+		if clickedSquare != nilSquare && clickedSquare == g.chessBoardGraphic.clickedPromotionSquare {
 			promotedPieceType := g.chessBoardGraphic.getPromotionPiece(clickedSquare)
-			promotedPieceColor := white
-			if !g.whitesTurn {
-				promotedPieceColor = black
+			promotionMoves := g.promotionLifeCycle.promotionMoves
+			g.cancelPromotion()
+			for _, move := range promotionMoves {
+				if move.Promotion == promotedPieceType {
+					g.playMove(move)
+					break
+				}
 			}
-			g.chessBoard.setPiece(g.chessBoardGraphic.promotionSquare, chessPiece{promotedPieceType, promotedPieceColor})
-			g.whitesTurn = !g.whitesTurn
-			g.chessBoardGraphic.promotionSquare = nilSquare
 		}
 
 	}
 
 }
 
+// Plays a legal move for the side to move
+func (g *ChessGame) playMove(move chess.Move) {
+	if g.playingNetwork() {
+		g.sendMove(move)
+		return
+	}
+
+	// Stop analysis of the position being left
+	g.stopEngine()
+
+	if err := g.game.Play(move); err != nil {
+		log.Print(err)
+		return
+	}
+	g.pressClock()
+	g.afterMove()
+}
+
+// Logs the move just played in SAN, then how the game ended or a draw the side to move may now claim
+func (g *ChessGame) afterMove() {
+	sanMoves := g.game.MovesSAN()
+	if g.game.Turn() == chess.Black {
+		log.Printf("%d. %s", g.game.FullmoveNumber(), sanMoves[len(sanMoves)-1])
+	} else {
+		log.Printf("%d... %s", g.game.FullmoveNumber()-1, sanMoves[len(sanMoves)-1])
+	}
+
+	if outcome := g.game.Outcome(); outcome.IsOver() {
+		log.Print(outcome)
+	} else if termination := g.game.ClaimableDraw(); termination != chess.NotTerminated {
+		log.Printf("%s may claim a draw by %s (press D)", g.game.Turn(), termination)
+	}
+}
+
+// Logs the outcome of a game ended by a player, such as by a resignation
+func (g *ChessGame) endGame() {
+	g.stopEngine()
+	g.stopClock()
+	g.cancelPromotion()
+	log.Print(g.game.Outcome())
+}
+
+// Ends the game in a draw if the side to move is entitled to claim one.  Over the network the server decides,
+// and offers a draw to the opponent when there is none to claim.
+func (g *ChessGame) claimDraw() {
+	if g.playingNetwork() {
+		g.sendDraw()
+		return
+	}
+	if g.game.ClaimDraw() {
+		g.endGame()
+	}
+}
+
+// Ends the game in a draw by agreement.  Over the network the draw is offered, or the opponent's offer accepted.
+func (g *ChessGame) agreeDraw() {
+	if g.playingNetwork() {
+		g.sendDraw()
+		return
+	}
+	g.game.AgreeDraw()
+	g.endGame()
+}
+
+// Resigns for the side to move, or for the player over the network
+func (g *ChessGame) resign() {
+	if g.playingNetwork() {
+		if err := g.network.client.Resign(); err != nil {
+			log.Print(err)
+		}
+		return
+	}
+	g.game.Resign(g.game.Turn())
+	g.endGame()
+}
+
+// Takes back the last move.  A pending promotion is cancelled instead, as its move has not been played yet.
+func (g *ChessGame) undoMove() {
+	g.stopEngine()
+	if g.promotionLifeCycle.promotionInProgress {
+		g.cancelPromotion()
+		return
+	}
+
+	move, ok := g.game.Undo()
+	if !ok {
+		return
+	}
+	g.showCurrentPosition()
+	g.resetBoardGraphicState()
+	san, _ := g.game.SAN(move)
+	log.Printf("Took back %s", san)
+}
+
+// Replays the last move taken back
+func (g *ChessGame) redoMove() {
+	g.stopEngine()
+	g.cancelPromotion()
+
+	if _, ok := g.game.Redo(); !ok {
+		return
+	}
+	g.showCurrentPosition()
+	g.afterMove()
+	g.resetBoardGraphicState()
+}
+
+func (g *ChessGame) cancelPromotion() {
+	g.chessBoardGraphic.promotionSquare = nilSquare
+	g.promotionLifeCycle.resetPromotionLifeCycle()
+}
+
+// Clears selections and animations that belong to the position being left
+func (g *ChessGame) resetBoardGraphicState() {
+	g.chessBoardGraphic.clickedSquare = nilSquare
+	g.chessBoardGraphic.clickedPromotionSquare = nilSquare
+	g.chessBoardGraphic.possibleMoves = nil
+	g.chessBoardGraphic.confetti = nil
+}
+
 // Base colors
 var (
 	lightSquareColor = color.RGBA{238, 238, 238, 255} // Off white
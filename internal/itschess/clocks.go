@@ -0,0 +1,102 @@
+package itschess
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	clockMargin = 20
+	clockHeight = 60
+)
+
+var (
+	runningClockColor = color.RGBA{255, 255, 255, 255}
+	stoppedClockColor = color.RGBA{200, 200, 200, 255}
+	clockTextColor    = color.RGBA{0, 0, 0, 255}
+	flaggedClockColor = color.RGBA{200, 0, 0, 255}
+)
+
+// Starts the clock of the side to move, unless the game is already over
+func (g *ChessGame) startClock(control chess.TimeControl) {
+	g.clock = chess.NewClock(control)
+	if !g.game.Outcome().IsOver() {
+		g.clock.Start(g.game.Turn(), time.Now())
+	}
+}
+
+// Ends the game if the side to move has run out of time
+func (g *ChessGame) updateClock() {
+	if side := g.clock.Flagged(time.Now()); side != 0 {
+		g.game.TimeOut(side)
+		g.endGame()
+	}
+}
+
+// Hands the turn over on the clock once a move is complete, or stops it when the move ended the game
+func (g *ChessGame) pressClock() {
+	if g.clock == nil {
+		return
+	}
+	now := time.Now()
+	g.clock.Press(now)
+	if g.game.Outcome().IsOver() {
+		g.clock.Stop(now)
+	}
+}
+
+func (g *ChessGame) stopClock() {
+	if g.clock != nil {
+		g.clock.Stop(time.Now())
+	}
+}
+
+// Draws each side's clock level with its side of the board: black's at the top and white's at the bottom,
+// unless the board is flipped
+func (g *ChessGame) drawClocks(screen *ebiten.Image) {
+	now := time.Now()
+	x := float32(g.chessBoardGraphic.boardWidth() + clockMargin)
+	width := float32(g.chessBoardGraphic.sidePanelWidth - 2*clockMargin)
+
+	bottom := chess.White
+	if g.chessBoardGraphic.flipped {
+		bottom = chess.Black
+	}
+	for _, side := range []chess.Color{chess.Black, chess.White} {
+		y := float32(clockMargin)
+		if side == bottom {
+			y = float32(g.chessBoardGraphic.boardHeight() - clockMargin - clockHeight)
+		}
+
+		background := stoppedClockColor
+		if g.clock.Running() == side {
+			background = runningClockColor
+		}
+		vector.DrawFilledRect(screen, x, y, width, clockHeight, background, true)
+		vector.StrokeRect(screen, x, y, width, clockHeight, 2, clockTextColor, true)
+
+		remaining := g.clock.Remaining(side, now)
+		textColor := clockTextColor
+		if remaining == 0 {
+			textColor = flaggedClockColor
+		}
+		ebitentext.Draw(screen, formatClock(remaining), mplusNormalFont, int(x)+clockMargin, int(y)+clockHeight/2+8, textColor)
+	}
+}
+
+// Formats the time on a clock as h:mm:ss, m:ss, or with tenths of a second under ten seconds
+func formatClock(remaining time.Duration) string {
+	switch {
+	case remaining >= time.Hour:
+		return fmt.Sprintf("%d:%02d:%02d", int(remaining.Hours()), int(remaining.Minutes())%60, int(remaining.Seconds())%60)
+	case remaining < 10*time.Second:
+		return fmt.Sprintf("0:%04.1f", float64(remaining.Truncate(100*time.Millisecond))/float64(time.Second))
+	}
+	return fmt.Sprintf("%d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60)
+}
@@ -0,0 +1,229 @@
+package itschess
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// Thinking time per move when neither a depth nor a time is given
+const defaultEngineMoveTime = time.Second
+
+// How long a stopped search may take to give its move before the engine is taken to have stopped answering
+const engineStopTimeout = 2 * time.Second
+
+// Layout of the analysis under the move list: a line for the depth and score, then the principal variation
+const (
+	analysisLines      = 4
+	analysisLineHeight = 18
+)
+
+var analysisTextColor = color.RGBA{0, 0, 0, 255}
+
+// The engine playing one or both sides of a ChessGame, or analyzing the game for the player.  Searches run
+// on their own goroutine so the window keeps responding; Update collects the move once it is found.
+type engineOpponent struct {
+	engine   *chess.Engine
+	colors   [3]bool // By color, whether the engine plays that side
+	analyze  bool    // Whether the engine analyzes the positions where the player is to move
+	limits   chess.SearchLimits
+	results  chan engineResult
+	thinking bool // Whether a search, for a move or analysis, is running or has a result waiting
+	analysis *engineAnalysis
+}
+
+// The latest progress report of the engine's search.  Reports arrive on the search's goroutine, so the lock is
+// held while reading or writing them.
+type engineAnalysis struct {
+	lock sync.Mutex
+	fen  string // The position searched, "" until the first report
+	info chess.SearchInfo
+	pv   []string // info.PV in SAN
+}
+
+type engineResult struct {
+	fen  string // The position the move was found for
+	move chess.Move
+}
+
+// Hands the sides named by colors ("white", "black", "both", or "" for neither) to engine.  With analyze set,
+// engine also analyzes the positions where the player is to move.
+func (g *ChessGame) setEngineOpponent(engine *chess.Engine, colors string, analyze bool, limits chess.SearchLimits) error {
+	var engineColors [3]bool
+	switch colors {
+	case "":
+	case "white":
+		engineColors[chess.White] = true
+	case "black":
+		engineColors[chess.Black] = true
+	case "both":
+		engineColors[chess.White], engineColors[chess.Black] = true, true
+	default:
+		return fmt.Errorf("engine color must be white, black or both, got %q", colors)
+	}
+	if limits.Depth == 0 && limits.MoveTime == 0 {
+		limits.MoveTime = defaultEngineMoveTime
+	}
+
+	g.opponent = engineOpponent{
+		engine:   engine,
+		colors:   engineColors,
+		analyze:  analyze,
+		limits:   limits,
+		results:  make(chan engineResult, 1),
+		analysis: &engineAnalysis{},
+	}
+	return nil
+}
+
+func (g *ChessGame) engineToMove() bool {
+	return g.opponent.colors[g.game.Turn()]
+}
+
+// Whether a player plays against the engine, rather than the engine against itself or nobody
+func (g *ChessGame) playingEngine() bool {
+	return g.opponent.colors[chess.White] != g.opponent.colors[chess.Black]
+}
+
+// Starts a search for the side to move, or plays its result once it is in
+func (g *ChessGame) updateEngine() {
+	if !g.opponent.thinking {
+		g.startEngine(g.opponent.limits)
+		return
+	}
+
+	select {
+	case result := <-g.opponent.results:
+		g.opponent.thinking = false
+		// A move found for a position that has since been left is thrown away, and the search restarts
+		if result.fen != g.game.FEN() {
+			return
+		}
+		if result.move == (chess.Move{}) {
+			// The engine has stopped answering, so the player gets its side rather than waiting forever
+			log.Print("The engine did not return a move, handing its side over to the player")
+			g.opponent.colors = [3]bool{}
+			return
+		}
+		g.playMove(result.move)
+	default:
+	}
+}
+
+// Starts analyzing the position on the board if the engine isn't already.  The analysis runs until the
+// position changes.
+func (g *ChessGame) updateAnalysis() {
+	if !g.opponent.thinking {
+		g.startEngine(chess.SearchLimits{})
+	}
+}
+
+func (g *ChessGame) startEngine(limits chess.SearchLimits) {
+	fen, results, analysis := g.game.FEN(), g.opponent.results, g.opponent.analysis
+
+	g.opponent.thinking = true
+	g.opponent.engine.Start(g.game, limits, func(info chess.SearchInfo) {
+		logSearchInfo(info)
+		analysis.update(fen, info)
+	}, func(move chess.Move) {
+		results <- engineResult{fen: fen, move: move}
+	})
+}
+
+// Interrupts a running search and waits for it to finish, so that the board can change underneath it.  An
+// engine that doesn't finish within engineStopTimeout is quit, and the player gets its sides.
+func (g *ChessGame) stopEngine() {
+	if !g.opponent.thinking {
+		return
+	}
+	g.opponent.engine.Stop()
+	select {
+	case <-g.opponent.results:
+	case <-time.After(engineStopTimeout):
+		log.Printf("The engine did not stop within %v, handing its side over to the player", engineStopTimeout)
+		g.opponent.engine.Close()
+		g.opponent.colors = [3]bool{}
+		g.opponent.analyze = false
+	}
+	g.opponent.thinking = false
+}
+
+func logSearchInfo(info chess.SearchInfo) {
+	score := fmt.Sprintf("%+.2f", float64(info.Score)/100)
+	if info.MateIn != 0 {
+		score = fmt.Sprintf("mate %d", info.MateIn)
+	}
+	pv := make([]string, len(info.PV))
+	for i, move := range info.PV {
+		pv[i] = move.String()
+	}
+	log.Printf("Engine depth %d score %s nodes %d time %v pv %s", info.Depth, score, info.Nodes, info.Elapsed.Round(time.Millisecond), strings.Join(pv, " "))
+}
+
+// Keeps a search's report for the position fen, with its principal variation written in SAN
+func (a *engineAnalysis) update(fen string, info chess.SearchInfo) {
+	var pv []string
+	if game, err := chess.NewGameFromFEN(fen); err == nil {
+		for _, move := range info.PV {
+			san, err := game.SAN(move)
+			if err != nil || game.Play(move) != nil {
+				break
+			}
+			pv = append(pv, san)
+		}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.fen, a.info, a.pv = fen, info, pv
+}
+
+// The latest report for the position fen
+func (a *engineAnalysis) latest(fen string) (chess.SearchInfo, []string, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.info, a.pv, a.fen == fen
+}
+
+// The height of the engine's analysis under the move list, 0 unless the engine analyzes the game
+func (g *ChessGame) analysisHeight() int {
+	if !g.opponent.analyze {
+		return 0
+	}
+	return analysisLines*analysisLineHeight + panelButtonGap
+}
+
+// Shows the depth, score and principal variation of the engine's analysis of the position on the board.  The
+// score is from white's point of view.
+func (g *ChessGame) drawAnalysis(screen *ebiten.Image) {
+	if !g.opponent.analyze {
+		return
+	}
+	game := g.displayedGame()
+	info, pv, ok := g.opponent.analysis.latest(game.FEN())
+	if !ok {
+		return
+	}
+
+	score, mateIn := info.Score, info.MateIn
+	if game.Turn() == chess.Black {
+		score, mateIn = -score, -mateIn
+	}
+	summary := fmt.Sprintf("Depth %d  %+.2f", info.Depth, float64(score)/100)
+	if mateIn != 0 {
+		summary = fmt.Sprintf("Depth %d  #%d", info.Depth, mateIn)
+	}
+
+	x, y, width, height := g.moveListBounds()
+	lines := append([]string{summary}, wrapText(strings.Join(pv, " "), mplusSmallFont, width)...)
+	for i, line := range lines[:min(len(lines), analysisLines)] {
+		ebitentext.Draw(screen, line, mplusSmallFont, x, y+height+(i+1)*analysisLineHeight, analysisTextColor)
+	}
+}
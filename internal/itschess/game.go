@@ -1,8 +1,13 @@
 package itschess
 
 import (
-	"math"
+	"fmt"
+	"log"
+	"os"
+	"time"
 
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/benwheeler12/itschess/internal/netplay"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -16,20 +21,203 @@ const (
 	startingWindowHeight int = 700
 )
 
-func StartGame() {
-	var game *Game = &Game{}
+// Settings that apply however a game is started
+type Options struct {
+	EngineColor    string        // Side played by the engine: "white", "black", "both", or "" to play without it
+	EngineDepth    int           // Maximum depth the engine searches to, 0 for no limit
+	EngineMoveTime time.Duration // Time the engine thinks per move, 0 for no limit.  One second if neither limit is set.
+	EngineWeights  string        // JSON file of evaluation weights for the engine, "" for the built in ones
+	EnginePath     string        // UCI engine program to use instead of the built-in engine, such as Stockfish
+	Analyze        bool          // Have the engine analyze the positions where the player is to move
+	Host           string        // Address to serve the game on for a player elsewhere to join, such as ":7878"
+	Join           string        // Address of a game served elsewhere to join instead of playing locally
+	NetworkColor   string        // Side to play over the network: "white", "black", or "" for whichever is free
+	TimeControl    string        // Time control for the clocks in the form chess.ParseTimeControl reads, "" for an untimed game
+	Book           string        // Polyglot opening book to show the moves of and for the engine to play from
+	BestBookMove   bool          // Have the engine play the book's most weighted move rather than pick at random
+	Syzygy         string        // Directories of Syzygy tablebase files, separated like PATH, to annotate positions and for the engine to play from
+	Setup          bool          // Set up the position on the board before play starts from it
+	Flip           bool          // Put black's side of the board at the bottom.  It is anyway when the player plays black.
+	AutoFlip       bool          // Keep the side to move at the bottom, for players taking turns at the same screen
+}
+
+func StartGame(options Options) error {
+	return runGame(chess.NewGame(), options)
+}
+
+// Starts a game of Chess960 from one of its starting positions, numbered from 0 to 959
+func StartChess960Game(position int, options Options) error {
+	game, err := chess.NewChess960Game(position)
+	if err != nil {
+		return err
+	}
+	return runGame(game, options)
+}
+
+// Starts a game from the position described by fen.  Returns an error without opening a window if fen is invalid.
+func StartGameFromFEN(fen string, options Options) error {
+	game, err := chess.NewGameFromFEN(fen)
+	if err != nil {
+		return err
+	}
+	return runGame(game, options)
+}
+
+// Starts a game from the final position of the first game in a PGN file.  Moves played extend that game.
+func StartGameFromPGN(path string, options Options) error {
+	pgn, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	game, err := chess.NewGameFromPGN(string(pgn))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return runGame(game, options)
+}
+
+func runGame(chessGame *chess.Game, options Options) error {
+	game := &Game{ChessGame{game: chessGame}}
+
+	if options.AutoFlip && (options.EngineColor != "" || options.Host != "" || options.Join != "") {
+		return fmt.Errorf("auto flip is only for players sharing a screen, not against the engine or over the network")
+	}
+
+	if options.Host != "" || options.Join != "" {
+		if options.EngineColor != "" {
+			return fmt.Errorf("the engine can't play a networked game")
+		}
+		if options.Analyze {
+			return fmt.Errorf("the engine can't analyze a networked game")
+		}
+		if options.Setup {
+			return fmt.Errorf("a networked game can't be set up")
+		}
+		addr := options.Join
+		if options.Host != "" {
+			// The host plays through its own server like the player joining it
+			server, err := netplay.Listen(options.Host, chessGame)
+			if err != nil {
+				return err
+			}
+			defer server.Close()
+			go server.Serve()
+			log.Printf("Serving the game on %s", server.Addr())
+			addr = server.Addr().String()
+		}
+		if err := game.joinNetworkGame(addr, options.NetworkColor); err != nil {
+			return err
+		}
+		defer game.network.client.Close()
+	}
+
+	if options.Book != "" {
+		book, err := chess.LoadOpeningBook(options.Book)
+		if err != nil {
+			return err
+		}
+		game.book = book
+	}
+
+	if options.Syzygy != "" {
+		tablebase, err := chess.OpenTablebase(options.Syzygy)
+		if err != nil {
+			return err
+		}
+		game.tablebase = tablebase
+	}
+
+	if options.EngineColor != "" || options.Analyze {
+		engine, err := newEngine(options)
+		if err != nil {
+			return err
+		}
+		defer engine.Close()
+		if game.book != nil {
+			selection := chess.WeightedBookMove
+			if options.BestBookMove {
+				selection = chess.BestBookMove
+			}
+			engine.SetOpeningBook(game.book, selection)
+		}
+		if game.tablebase != nil {
+			engine.SetTablebase(game.tablebase)
+		}
+		limits := chess.SearchLimits{Depth: options.EngineDepth, MoveTime: options.EngineMoveTime}
+		if err := game.setEngineOpponent(engine, options.EngineColor, options.Analyze, limits); err != nil {
+			return err
+		}
+	}
+
+	if options.Setup {
+		game.setup = &positionSetup{position: chessGame.Setup()}
+	}
+
+	windowWidth := startingWindowWidth + sidePanelWidth
+	game.chessBoardGraphic.sidePanelWidth = sidePanelWidth
+	if options.TimeControl != "" {
+		if game.playingNetwork() {
+			return fmt.Errorf("clocks can't be used in a networked game")
+		}
+		control, err := chess.ParseTimeControl(options.TimeControl)
+		if err != nil {
+			return err
+		}
+		// With a position to set up first, the clocks start once play does
+		if game.setup != nil {
+			game.setup.timeControl = &control
+		} else {
+			game.startClock(control)
+		}
+	}
+
+	// The player's own side goes at the bottom
+	flipped := options.Flip || options.EngineColor == "white" || (game.playingNetwork() && game.network.client.Color() == chess.Black)
+	game.autoFlip = options.AutoFlip
+
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
-	ebiten.SetWindowSize(startingWindowWidth, startingWindowHeight)
+	ebiten.SetWindowSize(windowWidth, startingWindowHeight)
 	ebiten.SetWindowTitle("It's Chess")
-	game.chessBoard.init()
-	game.chessBoardGraphic.init(point{0, 0}, math.Pi, 1, startingWindowWidth, startingWindowHeight)
+	game.chessBoardGraphic.init(point{0, 0}, flipped, windowWidth, startingWindowHeight)
 	game.mouseLifeCycle.resetMouseState()
 	game.promotionLifeCycle.resetPromotionLifeCycle()
-	game.whitesTurn = true
 
 	if err := ebiten.RunGame(game); err != nil {
 		panic(err)
 	}
+
+	return game.saveRecord()
+}
+
+// Starts the external engine named in options, or sets up the built-in one
+func newEngine(options Options) (*chess.Engine, error) {
+	if options.EnginePath != "" {
+		return chess.StartUCIEngine(options.EnginePath)
+	}
+	if options.EngineWeights != "" {
+		return chess.NewEngineWithWeights(options.EngineWeights)
+	}
+	return chess.NewEngine(), nil
+}
+
+// Writes the game to a timestamped PGN file in the working directory so it survives the window closing
+func (g *Game) saveRecord() error {
+	if len(g.game.Moves()) == 0 {
+		return nil
+	}
+
+	pgn, err := g.game.PGN()
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("itschess-%s.pgn", time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(path, []byte(pgn), 0644); err != nil {
+		return err
+	}
+	log.Printf("Game saved to %s", path)
+	return nil
 }
 
 func (g *Game) Update() error {
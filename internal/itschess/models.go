@@ -1,44 +1,9 @@
 package itschess
 
-type piece int
+import "github.com/benwheeler12/itschess/chess"
 
-type pieceColor int
-
-var emptyPiece = chessPiece{empty, nocolor}
 var nilSquare = vector2{-1, -1}
 
-const rotationFactor int = 2
-
-const (
-	nocolor pieceColor = iota
-	white
-	black
-)
-
-const (
-	empty piece = iota
-	pawn
-	bishop
-	knight
-	rook
-	queen
-	king
-)
-
-type chessPiece struct {
-	pieceType piece
-	color     pieceColor
-}
-
-type castlingState struct {
-	a1RookMoved    bool
-	a8RookMoved    bool
-	h1RookMoved    bool
-	h8RookMoved    bool
-	blackKingMoved bool
-	whiteKingMoved bool
-}
-
 type vector2 struct {
 	x int
 	y int
@@ -50,7 +15,7 @@ type point struct {
 }
 
 type mouseLifeCycle struct {
-	selectedPiece               chessPiece
+	selectedPiece               chess.Piece
 	selectedSquare              vector2
 	mouseClickedOnInvalidSquare bool
 	possibleMoveSquares         []vector2 //representing possible moves from currently selected piece
@@ -59,6 +24,7 @@ type mouseLifeCycle struct {
 type promotionLifeCycle struct {
 	promotionSquare     vector2
 	promotionInProgress bool
+	promotionMoves      []chess.Move // One move per promotion piece, played once a piece is chosen
 }
 
 type mouseState struct {
@@ -68,7 +34,7 @@ type mouseState struct {
 
 // Initializers:
 func (ms *mouseLifeCycle) resetMouseState() {
-	ms.selectedPiece = emptyPiece
+	ms.selectedPiece = chess.Piece{}
 	ms.mouseClickedOnInvalidSquare = false
 	ms.selectedSquare = nilSquare
 	ms.possibleMoveSquares = nil
@@ -77,4 +43,5 @@ func (ms *mouseLifeCycle) resetMouseState() {
 func (pfc *promotionLifeCycle) resetPromotionLifeCycle() {
 	pfc.promotionInProgress = false
 	pfc.promotionSquare = nilSquare
+	pfc.promotionMoves = nil
 }
@@ -0,0 +1,249 @@
+package itschess
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"slices"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Layout of the move list in the side panel
+const (
+	moveRowHeight     = 24
+	moveNumberWidth   = 40
+	moveColumnWidth   = 60
+	moveTextPadding   = 4
+	moveTextBaseline  = 17
+	moveListBorder    = 2
+	moveListScrollRow = 1 // Rows scrolled per notch of the mouse wheel
+)
+
+var (
+	moveListColor        = color.RGBA{245, 245, 245, 255}
+	moveTextColor        = color.RGBA{0, 0, 0, 255}
+	currentMoveColor     = color.RGBA{246, 246, 105, 255}
+	moveListBorderColor  = color.RGBA{0, 0, 0, 255}
+	moveNumberTextColor  = color.RGBA{110, 110, 110, 255}
+	earlierPositionColor = color.RGBA{30, 70, 150, 255}
+)
+
+// The moves of the game listed in the side panel.  Clicking a move shows the position after it, without
+// touching the game itself, so looking back loses none of the moves played since.
+type moveList struct {
+	view       *chess.Game  // An earlier position of the game being looked at, nil when showing the game as it stands
+	viewPly    int          // The number of the game's moves played in view
+	moves      []chess.Move // The game's moves that san was written for
+	san        []string
+	topRow     int // First row of moves shown
+	scrolledTo int // The ply last scrolled into view
+}
+
+// The game as it is shown on the board: an earlier position being looked at, or else the game itself
+func (g *ChessGame) displayedGame() *chess.Game {
+	if g.moveList.view != nil {
+		return g.moveList.view
+	}
+	return g.game
+}
+
+// The number of the game's moves played in the position shown on the board
+func (g *ChessGame) displayedPly() int {
+	if g.moveList.view != nil {
+		return g.moveList.viewPly
+	}
+	return len(g.game.Moves())
+}
+
+// Shows the position after the game's first ply moves, or the game as it stands if ply reaches its last move
+func (g *ChessGame) showPly(ply int) {
+	moves := g.game.Moves()
+	ply = max(0, min(ply, len(moves)))
+	if ply == g.displayedPly() {
+		return
+	}
+	g.cancelPromotion()
+	g.resetBoardGraphicState()
+	if ply == len(moves) {
+		g.moveList.view = nil
+		return
+	}
+
+	view, err := chess.NewGameFromFEN(g.game.StartFEN())
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, move := range moves[:ply] {
+		if err := view.Play(move); err != nil {
+			log.Print(err)
+			return
+		}
+	}
+	g.moveList.view = view
+	g.moveList.viewPly = ply
+}
+
+// Goes back to showing the game as it stands
+func (g *ChessGame) showCurrentPosition() {
+	g.moveList.view = nil
+}
+
+// Steps through the game with the arrow keys, Home and End, or jumps to a move clicked in the list
+func (g *ChessGame) updateMoveList() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		g.showPly(g.displayedPly() - 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		g.showPly(g.displayedPly() + 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		g.showPly(0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		g.showPly(len(g.game.Moves()))
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		if ply, ok := g.moveListPlyAt(vector2{x, y}); ok {
+			g.showPly(ply)
+		}
+	}
+
+	// Scroll with the mouse wheel, and keep the move shown on the board in view whenever it changes
+	rows, visibleRows := g.moveListRows(), g.visibleMoveRows()
+	if _, wheel := ebiten.Wheel(); wheel != 0 && g.positionInMoveList(ebiten.CursorPosition()) {
+		g.moveList.topRow -= int(wheel) * moveListScrollRow
+	}
+	if ply := g.displayedPly(); ply != g.moveList.scrolledTo {
+		g.moveList.scrolledTo = ply
+		if ply > 0 {
+			row, _ := g.moveCell(ply - 1)
+			g.moveList.topRow = max(min(g.moveList.topRow, row), row-visibleRows+1)
+		}
+	}
+	g.moveList.topRow = max(0, min(g.moveList.topRow, rows-visibleRows))
+}
+
+// The moves of the game in SAN, written again only when the game's moves change
+func (g *ChessGame) movesSAN() []string {
+	if moves := g.game.Moves(); !slices.Equal(moves, g.moveList.moves) {
+		g.moveList.moves = moves
+		g.moveList.san = g.game.MovesSAN()
+	}
+	return g.moveList.san
+}
+
+// The area of the side panel the moves are listed in, above the engine's analysis and the buttons to flip the
+// board, and between the clocks when there are any
+func (g *ChessGame) moveListBounds() (x, y, width, height int) {
+	x = g.chessBoardGraphic.boardWidth() + clockMargin
+	y = clockMargin
+	width = g.chessBoardGraphic.sidePanelWidth - 2*clockMargin
+	height = g.chessBoardGraphic.boardHeight() - 2*clockMargin - g.analysisHeight() - panelButtonHeight - panelButtonGap
+	if g.clock != nil {
+		y += clockHeight + clockMargin
+		height -= 2 * (clockHeight + clockMargin)
+	}
+	return x, y, width, max(height, 0)
+}
+
+func (g *ChessGame) positionInMoveList(mouseX, mouseY int) bool {
+	x, y, width, height := g.moveListBounds()
+	return mouseX >= x && mouseX < x+width && mouseY >= y && mouseY < y+height
+}
+
+func (g *ChessGame) visibleMoveRows() int {
+	_, _, _, height := g.moveListBounds()
+	return max((height-2*moveListBorder)/moveRowHeight, 1)
+}
+
+// The ply white's first move of the game was or would have been played at, counting from 0.  Games set up
+// from a position start later, and with black to move they start half a move in.
+func (g *ChessGame) firstPly() int {
+	ply := 2*(g.game.FullmoveNumber()-1) - len(g.game.Moves())
+	if g.game.Turn() == chess.Black {
+		ply++
+	}
+	return ply
+}
+
+// The row and column, 0 for white and 1 for black, the game's move at index is listed in
+func (g *ChessGame) moveCell(index int) (row, column int) {
+	first := g.firstPly()
+	ply := first + index
+	return ply/2 - first/2, ply % 2
+}
+
+func (g *ChessGame) moveListRows() int {
+	moves := len(g.game.Moves())
+	if moves == 0 {
+		return 0
+	}
+	row, _ := g.moveCell(moves - 1)
+	return row + 1
+}
+
+// The number of moves to show the position after for a click on a move in the list
+func (g *ChessGame) moveListPlyAt(mousePosition vector2) (int, bool) {
+	if !g.positionInMoveList(mousePosition.x, mousePosition.y) {
+		return 0, false
+	}
+	x, y, _, _ := g.moveListBounds()
+	column := (mousePosition.x - x - moveNumberWidth) / moveColumnWidth
+	if mousePosition.x < x+moveNumberWidth || column > 1 {
+		return 0, false
+	}
+	row := g.moveList.topRow + (mousePosition.y-y-moveListBorder)/moveRowHeight
+
+	first := g.firstPly()
+	index := (first/2+row)*2 + column - first
+	if index < 0 || index >= len(g.game.Moves()) {
+		return 0, false
+	}
+	return index + 1, true
+}
+
+// Lists the moves of the game by move number, white's then black's, with the move that led to the position on
+// the board highlighted.  The list is outlined in blue while an earlier position is shown.
+func (g *ChessGame) drawMoveList(screen *ebiten.Image) {
+	x, y, width, height := g.moveListBounds()
+	if height == 0 {
+		return
+	}
+	border := moveListBorderColor
+	if g.moveList.view != nil {
+		border = earlierPositionColor
+	}
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width), float32(height), moveListColor, true)
+
+	san := g.movesSAN()
+	first := g.firstPly()
+	current := g.displayedPly() - 1
+	for row := g.moveList.topRow; row < g.moveList.topRow+g.visibleMoveRows(); row++ {
+		rowY := y + moveListBorder + (row-g.moveList.topRow)*moveRowHeight
+		number := first/2 + row + 1
+		drawn := false
+		for column := range 2 {
+			index := (first/2+row)*2 + column - first
+			if index < 0 || index >= len(san) {
+				continue
+			}
+			drawn = true
+			cellX := x + moveNumberWidth + column*moveColumnWidth
+			if index == current {
+				vector.DrawFilledRect(screen, float32(cellX), float32(rowY), moveColumnWidth, moveRowHeight, currentMoveColor, true)
+			}
+			ebitentext.Draw(screen, san[index], mplusSmallFont, cellX+moveTextPadding, rowY+moveTextBaseline, moveTextColor)
+		}
+		if drawn {
+			ebitentext.Draw(screen, fmt.Sprintf("%d.", number), mplusSmallFont, x+moveTextPadding, rowY+moveTextBaseline, moveNumberTextColor)
+		}
+	}
+
+	vector.StrokeRect(screen, float32(x), float32(y), float32(width), float32(height), moveListBorder, border, true)
+}
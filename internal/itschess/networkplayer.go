@@ -0,0 +1,96 @@
+package itschess
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/benwheeler12/itschess/internal/netplay"
+)
+
+// The connection to a game played over the network.  The server's copy of the game is the real one: the
+// player's moves are sent to it and only played on the board once it sends them back.
+type networkOpponent struct {
+	client *netplay.Client
+}
+
+// Joins the networked game at addr as colors ("white", "black", or "" for whichever side is free) and takes
+// the board from the server
+func (g *ChessGame) joinNetworkGame(addr string, colors string) error {
+	var color chess.Color
+	switch colors {
+	case "":
+	case "white":
+		color = chess.White
+	case "black":
+		color = chess.Black
+	default:
+		return fmt.Errorf("network color must be white or black, got %q", colors)
+	}
+
+	client, err := netplay.Dial(addr, color)
+	if err != nil {
+		return err
+	}
+	g.network.client = client
+	g.game = client.Apply(g.game, <-client.Updates())
+	log.Printf("Joined %s as %s", addr, client.Color())
+	return nil
+}
+
+func (g *ChessGame) playingNetwork() bool {
+	return g.network.client != nil
+}
+
+// Whether the player waits for the opponent across the network to move
+func (g *ChessGame) networkOpponentToMove() bool {
+	return g.playingNetwork() && g.game.Turn() != g.network.client.Color()
+}
+
+// Plays whatever the server has sent since the last frame
+func (g *ChessGame) updateNetwork() {
+	for {
+		select {
+		case update := <-g.network.client.Updates():
+			g.applyNetworkUpdate(update)
+		default:
+			return
+		}
+	}
+}
+
+func (g *ChessGame) applyNetworkUpdate(update netplay.Update) {
+	switch {
+	case update.Err != nil:
+		log.Print(update.Err)
+	case update.DrawOffer:
+		log.Printf("%s offers a draw (press A to accept)", g.network.client.Color().Opponent())
+	case update.Game != nil:
+		wasOver := g.game.Outcome().IsOver()
+		g.game = update.Game
+		g.cancelPromotion()
+		g.resetBoardGraphicState()
+		if outcome := g.game.Outcome(); outcome.IsOver() && !wasOver {
+			log.Print(outcome)
+		}
+	default:
+		movesPlayed := len(g.game.Moves())
+		g.game = g.network.client.Apply(g.game, update)
+		if len(g.game.Moves()) > movesPlayed {
+			g.afterMove()
+		}
+	}
+}
+
+// Sends the player's move to the server, which sends it back to be played
+func (g *ChessGame) sendMove(move chess.Move) {
+	if err := g.network.client.Play(g.game, move); err != nil {
+		log.Print(err)
+	}
+}
+
+func (g *ChessGame) sendDraw() {
+	if err := g.network.client.Draw(); err != nil {
+		log.Print(err)
+	}
+}
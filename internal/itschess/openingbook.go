@@ -0,0 +1,75 @@
+package itschess
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Arrows drawn for book moves.  Colors are premultiplied by their alpha, as ebiten expects.
+var bookArrowColor = color.RGBA{30, 70, 150, 170}
+
+const (
+	minBookArrowWidth = 3
+	maxBookArrowWidth = 12
+)
+
+// Looks up the book moves of the position on the board whenever it changes, including earlier positions being
+// looked at.  They are logged with their share of the book's weight, and drawn on the board as arrows.
+func (g *ChessGame) updateBookMoves() {
+	game := g.displayedGame()
+	fen := game.FEN()
+	if fen == g.bookFEN {
+		return
+	}
+	g.bookFEN = fen
+	g.chessBoardGraphic.bookMoves = g.book.Moves(game)
+
+	moves := g.chessBoardGraphic.bookMoves
+	if len(moves) == 0 {
+		return
+	}
+	total := 0
+	for _, move := range moves {
+		total += move.Weight
+	}
+	descriptions := make([]string, len(moves))
+	for i, move := range moves {
+		san, _ := game.SAN(move.Move)
+		descriptions[i] = san
+		if total > 0 {
+			descriptions[i] += fmt.Sprintf(" %d%%", 100*move.Weight/total)
+		}
+	}
+	log.Printf("Book moves: %s", strings.Join(descriptions, ", "))
+}
+
+// Draws an arrow for each book move, thicker for moves with more weight.  Drawn before the board is turned
+// to face the player, like the squares.
+func (cbg *chessBoardGraphic) drawBookMoves(screen *ebiten.Image) {
+	heaviest := 0
+	for _, move := range cbg.bookMoves {
+		heaviest = max(heaviest, move.Weight)
+	}
+
+	for _, move := range cbg.bookMoves {
+		width := float32(minBookArrowWidth)
+		if heaviest > 0 {
+			width += float32(maxBookArrowWidth-minBookArrowWidth) * float32(move.Weight) / float32(heaviest)
+		}
+		fromX, fromY := cbg.squareCenter(move.Move.From)
+		toX, toY := cbg.squareCenter(move.Move.To)
+		vector.StrokeLine(screen, fromX, fromY, toX, toY, width, bookArrowColor, true)
+		vector.DrawFilledCircle(screen, toX, toY, width, bookArrowColor, true)
+	}
+}
+
+// The center of a square on the board image before it is turned
+func (cbg *chessBoardGraphic) squareCenter(square chess.Square) (float32, float32) {
+	return float32(cbg.squareWidth() * (float64(square.File()) + 0.5)), float32(cbg.squareHeight() * (float64(square.Rank()) + 0.5))
+}
@@ -0,0 +1,64 @@
+package itschess
+
+import (
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Turns the board around so the other side is at the bottom.  Turning it by hand switches auto flip off.
+func (g *ChessGame) flipBoard() {
+	g.chessBoardGraphic.flipped = !g.chessBoardGraphic.flipped
+	g.autoFlip = false
+}
+
+func (g *ChessGame) toggleAutoFlip() {
+	g.autoFlip = !g.autoFlip
+}
+
+// Whether both sides are played at this screen, rather than by the engine or over the network.  Auto flip is
+// only for such games.
+func (g *ChessGame) sharingScreen() bool {
+	return !g.opponent.colors[chess.White] && !g.opponent.colors[chess.Black] && !g.playingNetwork()
+}
+
+// Flips the board with B or the panel's buttons.  With auto flip on, as for players taking turns at the same
+// screen, the side to move is kept at the bottom.
+func (g *ChessGame) updateOrientation() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		g.flipBoard()
+	}
+	if g.setup != nil {
+		return
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		clickPanelButton(g.orientationButtons(), vector2{x, y})
+	}
+	if g.autoFlip && g.sharingScreen() {
+		g.chessBoardGraphic.flipped = g.game.Turn() == chess.Black
+	}
+}
+
+// The buttons below the move list and the engine's analysis.  Auto flip is greyed out unless the screen is
+// shared.
+func (g *ChessGame) orientationButtons() []panelButton {
+	x, y, width, height := g.moveListBounds()
+	half := (width - panelButtonGap) / 2
+	buttonY := y + height + g.analysisHeight() + panelButtonGap
+	autoFlip := panelButton{x + half + panelButtonGap, buttonY, half, panelButtonHeight, "Auto flip", g.autoFlip, g.toggleAutoFlip}
+	if !g.sharingScreen() {
+		autoFlip.checked, autoFlip.action = false, nil
+	}
+	return []panelButton{
+		{x, buttonY, half, panelButtonHeight, "Flip", false, g.flipBoard},
+		autoFlip,
+	}
+}
+
+func (g *ChessGame) drawOrientationButtons(screen *ebiten.Image) {
+	for _, button := range g.orientationButtons() {
+		button.draw(screen)
+	}
+}
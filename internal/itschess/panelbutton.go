@@ -0,0 +1,63 @@
+package itschess
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+)
+
+const (
+	panelButtonHeight   = 28
+	panelButtonGap      = 6
+	panelButtonBaseline = 19
+)
+
+var (
+	panelButtonColor        = color.RGBA{220, 220, 220, 255}
+	checkedButtonColor      = color.RGBA{246, 246, 105, 255}
+	panelButtonTextColor    = color.RGBA{0, 0, 0, 255}
+	disabledButtonTextColor = color.RGBA{150, 150, 150, 255}
+)
+
+// A button of the side panel.  Checked buttons, for settings that are on, are drawn highlighted, and buttons
+// without an action are greyed out.
+type panelButton struct {
+	x, y, width, height int
+	label               string
+	checked             bool
+	action              func()
+}
+
+func (b panelButton) contains(mousePosition vector2) bool {
+	return mousePosition.x >= b.x && mousePosition.x < b.x+b.width && mousePosition.y >= b.y && mousePosition.y < b.y+b.height
+}
+
+func (b panelButton) draw(screen *ebiten.Image) {
+	background := panelButtonColor
+	if b.checked {
+		background = checkedButtonColor
+	}
+	vector.DrawFilledRect(screen, float32(b.x), float32(b.y), float32(b.width), float32(b.height), background, true)
+	vector.StrokeRect(screen, float32(b.x), float32(b.y), float32(b.width), float32(b.height), 1, panelButtonTextColor, true)
+	textColor := panelButtonTextColor
+	if b.action == nil {
+		textColor = disabledButtonTextColor
+	}
+	labelWidth := font.MeasureString(mplusSmallFont, b.label).Ceil()
+	ebitentext.Draw(screen, b.label, mplusSmallFont, b.x+(b.width-labelWidth)/2, b.y+panelButtonBaseline, textColor)
+}
+
+// Presses the button under the mouse, if there is one
+func clickPanelButton(buttons []panelButton, mousePosition vector2) {
+	for _, button := range buttons {
+		if button.contains(mousePosition) {
+			if button.action != nil {
+				button.action()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package itschess
+
+import (
+	"image/color"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/benwheeler12/itschess/chess"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+)
+
+// Layout of the setup panel: the palette of pieces, then the buttons, then why the position can't be played
+const (
+	paletteCellSize = 40
+	setupLineHeight = 20
+)
+
+var setupProblemColor = color.RGBA{200, 0, 0, 255}
+
+// The palette's rows, with white's pieces in the first column and black's in the second
+var palettePieceTypes = []chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn}
+
+// A position being set up on the board before play starts from it.  Pieces are dragged onto the board from the
+// palette, dragged between squares, and taken off by dragging them off the board or right clicking them.
+type positionSetup struct {
+	position    chess.Setup
+	heldPiece   chess.Piece        // The piece being dragged, the zero Piece when none is
+	problem     string             // Why play couldn't start from the position, until it changes
+	timeControl *chess.TimeControl // The clocks to start once play starts, nil for an untimed game
+}
+
+// Leaves the game to set up a position, starting from position
+func (g *ChessGame) startSetup(position chess.Setup) {
+	g.stopEngine()
+	g.cancelPromotion()
+	g.resetBoardGraphicState()
+	g.showCurrentPosition()
+	g.chessBoardGraphic.bookMoves = nil
+	g.bookFEN = ""
+	g.setup = &positionSetup{position: position}
+	log.Print("Setting up a position: drag pieces from the palette, right click to remove them, Enter to play")
+}
+
+// Starts a new game from the position set up, or shows why it can't be played
+func (g *ChessGame) playSetup() {
+	game, err := chess.NewGameFromSetup(g.setup.position)
+	if err != nil {
+		g.setup.problem = err.Error()
+		log.Print(err)
+		return
+	}
+
+	g.game = game
+	if g.setup.timeControl != nil {
+		g.startClock(*g.setup.timeControl)
+	}
+	g.setup = nil
+	log.Printf("Playing from %s", game.FEN())
+}
+
+func (g *ChessGame) updateSetup() {
+	x, y := ebiten.CursorPosition()
+	mousePosition := vector2{x, y}
+	square, onBoard := g.setupSquareAt(mousePosition)
+	setup := g.setup
+
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		if onBoard {
+			setup.heldPiece = setup.position.Pieces[square]
+			setup.setPiece(square, chess.Piece{})
+		} else if piece, ok := g.palettePieceAt(mousePosition); ok {
+			setup.heldPiece = piece
+		} else {
+			clickPanelButton(g.setupButtons(), mousePosition)
+		}
+	case inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft):
+		// A piece dropped off the board is taken off it
+		if onBoard && setup.heldPiece != (chess.Piece{}) {
+			setup.setPiece(square, setup.heldPiece)
+		}
+		setup.heldPiece = chess.Piece{}
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && onBoard:
+		setup.setPiece(square, chess.Piece{})
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		g.playSetup()
+	}
+}
+
+// The square of the board under the mouse
+func (g *ChessGame) setupSquareAt(mousePosition vector2) (chess.Square, bool) {
+	if !g.chessBoardGraphic.positionInGraphic(mousePosition) {
+		return chess.NoSquare, false
+	}
+	square := boardSquare(g.chessBoardGraphic.getSquareOfMousePosition(mousePosition))
+	return square, square != chess.NoSquare
+}
+
+func (s *positionSetup) setPiece(square chess.Square, piece chess.Piece) {
+	s.position.Pieces[square] = piece
+	s.changed()
+}
+
+// Forgets the position's problem, and an en passant square the position no longer allows
+func (s *positionSetup) changed() {
+	s.problem = ""
+	if !slices.Contains(s.position.EnPassantSquares(), s.position.EnPassant) {
+		s.position.EnPassant = chess.NoSquare
+	}
+}
+
+// Moves the en passant square on to the next square the position allows, then back to none
+func (s *positionSetup) nextEnPassantSquare() {
+	squares := s.position.EnPassantSquares()
+	next := slices.Index(squares, s.position.EnPassant) + 1
+	s.position.EnPassant = chess.NoSquare
+	if next < len(squares) {
+		s.position.EnPassant = squares[next]
+	}
+	s.changed()
+}
+
+// Takes every piece off the board
+func (s *positionSetup) clear() {
+	s.position = chess.Setup{Turn: s.position.Turn, EnPassant: chess.NoSquare}
+	s.changed()
+}
+
+// Sets up the starting position
+func (s *positionSetup) reset() {
+	s.position = chess.NewGame().Setup()
+	s.changed()
+}
+
+// The top left corner of the palette cell of a piece, centered in its half of the panel
+func (g *ChessGame) paletteCell(row int, column int) (int, int) {
+	half := (g.chessBoardGraphic.sidePanelWidth - 2*clockMargin) / 2
+	x := g.chessBoardGraphic.boardWidth() + clockMargin + column*half + (half-paletteCellSize)/2
+	return x, clockMargin + row*paletteCellSize
+}
+
+func (g *ChessGame) palettePieceAt(mousePosition vector2) (chess.Piece, bool) {
+	for row, pieceType := range palettePieceTypes {
+		for column, side := range []chess.Color{chess.White, chess.Black} {
+			x, y := g.paletteCell(row, column)
+			if mousePosition.x >= x && mousePosition.x < x+paletteCellSize && mousePosition.y >= y && mousePosition.y < y+paletteCellSize {
+				return chess.Piece{Type: pieceType, Color: side}, true
+			}
+		}
+	}
+	return chess.Piece{}, false
+}
+
+// The buttons below the palette, labelled with the choices they make for the position
+func (g *ChessGame) setupButtons() []panelButton {
+	setup := g.setup
+	castling := &setup.position.Castling
+	x := g.chessBoardGraphic.boardWidth() + clockMargin
+	width := g.chessBoardGraphic.sidePanelWidth - 2*clockMargin
+	half := (width - panelButtonGap) / 2
+	rowY := func(row int) int {
+		return clockMargin + len(palettePieceTypes)*paletteCellSize + clockMargin + row*(panelButtonHeight+panelButtonGap)
+	}
+	toggle := func(right *bool) func() {
+		return func() {
+			*right = !*right
+			setup.changed()
+		}
+	}
+
+	turn := "White to move"
+	if setup.position.Turn == chess.Black {
+		turn = "Black to move"
+	}
+	return []panelButton{
+		{x, rowY(0), width, panelButtonHeight, turn, false, func() {
+			setup.position.Turn = setup.position.Turn.Opponent()
+			setup.changed()
+		}},
+		{x, rowY(1), half, panelButtonHeight, "W O-O", castling.WhiteKingside, toggle(&castling.WhiteKingside)},
+		{x + half + panelButtonGap, rowY(1), half, panelButtonHeight, "W O-O-O", castling.WhiteQueenside, toggle(&castling.WhiteQueenside)},
+		{x, rowY(2), half, panelButtonHeight, "B O-O", castling.BlackKingside, toggle(&castling.BlackKingside)},
+		{x + half + panelButtonGap, rowY(2), half, panelButtonHeight, "B O-O-O", castling.BlackQueenside, toggle(&castling.BlackQueenside)},
+		{x, rowY(3), width, panelButtonHeight, "En passant: " + setup.position.EnPassant.String(), false, setup.nextEnPassantSquare},
+		{x, rowY(4), half, panelButtonHeight, "Clear", false, setup.clear},
+		{x + half + panelButtonGap, rowY(4), half, panelButtonHeight, "Reset", false, setup.reset},
+		{x, rowY(5), width, panelButtonHeight, "Play", false, g.playSetup},
+	}
+}
+
+// Draws the position being set up, with the palette and buttons in the side panel and the held piece under the
+// mouse
+func (g *ChessGame) drawSetup(screen *ebiten.Image) {
+	cbg := &g.chessBoardGraphic
+	screen.DrawImage(cbg.drawPosition(&g.setup.position), &ebiten.DrawImageOptions{})
+
+	scale := paletteCellSize * pieceScale / cbg.pieceWidth()
+	for row, pieceType := range palettePieceTypes {
+		for column, side := range []chess.Color{chess.White, chess.Black} {
+			x, y := g.paletteCell(row, column)
+			squareColor := lightSquareColor
+			if (row+column)%2 == 1 {
+				squareColor = darkSquareColor
+			}
+			vector.DrawFilledRect(screen, float32(x), float32(y), paletteCellSize, paletteCellSize, squareColor, true)
+			cbg.drawPieceAt(screen, chess.Piece{Type: pieceType, Color: side}, float64(x+paletteCellSize/2), float64(y+paletteCellSize/2), scale)
+		}
+	}
+
+	var bottom int
+	for _, button := range g.setupButtons() {
+		button.draw(screen)
+		bottom = max(bottom, button.y+button.height)
+	}
+
+	x := cbg.boardWidth() + clockMargin
+	for i, line := range wrapText(g.setup.problem, mplusSmallFont, cbg.sidePanelWidth-2*clockMargin) {
+		ebitentext.Draw(screen, line, mplusSmallFont, x, bottom+clockMargin+(i+1)*setupLineHeight, setupProblemColor)
+	}
+
+	if g.setup.heldPiece != (chess.Piece{}) {
+		mouseX, mouseY := ebiten.CursorPosition()
+		cbg.drawPieceAt(screen, g.setup.heldPiece, float64(mouseX), float64(mouseY), 1)
+	}
+}
+
+// Breaks text into lines no wider than width, between words
+func wrapText(text string, face font.Face, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && font.MeasureString(face, line+" "+word).Ceil() > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package itschess
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	ebitentext "github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+)
+
+const tablebaseMargin = 8
+
+var (
+	tablebaseBackgroundColor = color.RGBA{0, 0, 0, 160}
+	tablebaseTextColor       = color.RGBA{255, 255, 255, 255}
+)
+
+// Probes the tablebase whenever the position on the board changes, including earlier positions being looked
+// at.  The result is logged and annotated on the board, from the point of view of the side to move.  Positions
+// the tablebase doesn't cover have no annotation.
+func (g *ChessGame) updateTablebase() {
+	game := g.displayedGame()
+	fen := game.FEN()
+	if fen == g.tablebaseFEN {
+		return
+	}
+	g.tablebaseFEN = fen
+	g.tablebaseAnnotation = ""
+
+	if game.Outcome().IsOver() {
+		return
+	}
+	result, err := g.tablebase.Probe(game)
+	if err != nil {
+		return
+	}
+	g.tablebaseAnnotation = fmt.Sprintf("%v (%v to move)", result, game.Turn())
+	log.Printf("Tablebase: %s", g.tablebaseAnnotation)
+}
+
+// Draws the tablebase annotation in the top left corner of the board
+func (g *ChessGame) drawTablebase(screen *ebiten.Image) {
+	if g.tablebaseAnnotation == "" {
+		return
+	}
+	bounds, _ := font.BoundString(mplusNormalFont, g.tablebaseAnnotation)
+	width := (bounds.Max.X - bounds.Min.X).Ceil()
+	height := (bounds.Max.Y - bounds.Min.Y).Ceil()
+	vector.DrawFilledRect(screen, 0, 0, float32(width+2*tablebaseMargin), float32(height+2*tablebaseMargin), tablebaseBackgroundColor, true)
+	ebitentext.Draw(screen, g.tablebaseAnnotation, mplusNormalFont, tablebaseMargin-bounds.Min.X.Floor(), tablebaseMargin-bounds.Min.Y.Floor(), tablebaseTextColor)
+}
@@ -1,6 +1,10 @@
 package itschess
 
-import "image/color"
+import (
+	"image/color"
+
+	"github.com/benwheeler12/itschess/chess"
+)
 
 func abs(x int) int {
 	if x < 0 {
@@ -18,16 +22,6 @@ func contains[T comparable](slice []T, element T) bool {
 	return false
 }
 
-func (pc pieceColor) oppositeColor() pieceColor {
-	if pc == nocolor {
-		panic("Cannot get opposite color of nocolor")
-	}
-	if pc == white {
-		return black
-	}
-	return white
-}
-
 func (v vector2) add(v2 vector2) vector2 {
 	return vector2{v.x + v2.x, v.y + v2.y}
 }
@@ -50,25 +44,6 @@ func filter[T any](slice []T, f func(T) bool) []T {
 	return filtered
 }
 
-func rotateChessCoord(square vector2, rotationFactor int) vector2 {
-	// normalize between 0 and 3
-	rotationFactor = ((rotationFactor % 4) + 4) % 4
-
-	x, y := 7-square.x, square.y
-
-	switch rotationFactor {
-	case 0:
-		return vector2{x, y}
-	case 1:
-		return vector2{7 - y, x}
-	case 2:
-		return vector2{7 - x, 7 - y}
-	case 3:
-		return vector2{y, 7 - x}
-	}
-	return vector2{x, y} // default case, shouldn't be reached
-}
-
 func tintColor(originalColor color.RGBA, tint color.RGBA, alpha float64) color.RGBA {
 	// Ensure alpha is in range [0,1]
 	if alpha < 0 || alpha > 1 {
@@ -83,3 +58,8 @@ func tintColor(originalColor color.RGBA, tint color.RGBA, alpha float64) color.R
 	}
 
 }
+
+// The chess square at board coordinates
+func boardSquare(square vector2) chess.Square {
+	return chess.SquareAt(square.x, square.y)
+}
@@ -0,0 +1,257 @@
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+const (
+	dialTimeout       = 10 * time.Second
+	reconnectInterval = 2 * time.Second // Wait between attempts to rejoin after losing the connection
+)
+
+// What the server sent a client.  Exactly one field is set.
+type Update struct {
+	Game      *chess.Game // The whole game, sent on joining and whenever the client's copy may be out of step
+	Move      chess.Move  // A move either side played, to be played on the game after Ply moves
+	Ply       int
+	DrawOffer bool  // The opponent offers a draw, which Draw accepts
+	Err       error // A request the server refused, or a lost connection the client is rejoining
+}
+
+// A player's connection to a Server.  A client that loses its connection rejoins by itself and is sent the
+// whole game again.
+type Client struct {
+	addr      string
+	updates   chan Update
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu    sync.Mutex
+	peer  *peer // nil while rejoining
+	color chess.Color
+	token string
+}
+
+// Joins the game served at addr as color, or whichever side is free if color is zero.  The first update is the
+// game as it stands.
+func Dial(addr string, color chess.Color) (*Client, error) {
+	request := message{}
+	if color != 0 {
+		request.Color = color.String()
+	}
+	p, state, err := join(addr, request)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{addr: addr, updates: make(chan Update, 16), closed: make(chan struct{})}
+	if err := c.joined(p, state); err != nil {
+		p.conn.Close()
+		return nil, err
+	}
+	go c.receive(p)
+	return c, nil
+}
+
+// Connects to the server and sends a join request, returning the state it answers with
+func join(addr string, request message) (*peer, message, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, message{}, err
+	}
+	p := newPeer(conn)
+
+	request.Type = joinMessage
+	var reply message
+	if err = p.send(request); err == nil {
+		reply, err = p.receive()
+	}
+	switch {
+	case err != nil:
+	case reply.Type == errorMessage:
+		err = errors.New(reply.Error)
+	case reply.Type != stateMessage:
+		err = fmt.Errorf("expected the game from the server, got %q", reply.Type)
+	default:
+		return p, reply, nil
+	}
+	conn.Close()
+	return nil, message{}, err
+}
+
+// Takes the seat given by the server and passes on the game
+func (c *Client) joined(p *peer, state message) error {
+	color, err := parseColor(state.Color)
+	if err != nil {
+		return err
+	}
+	game, err := gameOf(state)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.peer, c.color, c.token = p, color, state.Token
+	c.mu.Unlock()
+	c.update(Update{Game: game})
+	return nil
+}
+
+// Passes on the server's messages until the connection drops, then rejoins
+func (c *Client) receive(p *peer) {
+	for {
+		msg, err := p.receive()
+		if err != nil {
+			break
+		}
+		switch msg.Type {
+		case stateMessage:
+			game, err := gameOf(msg)
+			if err != nil {
+				c.update(Update{Err: fmt.Errorf("the server sent a game that can't be replayed: %w", err)})
+				continue
+			}
+			c.update(Update{Game: game})
+		case moveMessage:
+			move, err := chess.ParseLongAlgebraic(msg.Move)
+			if err != nil {
+				c.Sync()
+				continue
+			}
+			c.update(Update{Move: move, Ply: msg.Ply})
+		case drawOfferMessage:
+			c.update(Update{DrawOffer: true})
+		case errorMessage:
+			c.update(Update{Err: errors.New(msg.Error)})
+		}
+	}
+
+	c.mu.Lock()
+	c.peer = nil
+	c.mu.Unlock()
+	p.conn.Close()
+
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+	c.update(Update{Err: errors.New("lost the connection to the server, rejoining")})
+	c.rejoin()
+}
+
+// Tries to take the client's seat back until it succeeds or the client is closed
+func (c *Client) rejoin() {
+	for {
+		c.mu.Lock()
+		token := c.token
+		c.mu.Unlock()
+
+		p, state, err := join(c.addr, message{Token: token})
+		if err == nil {
+			select {
+			case <-c.closed:
+				p.conn.Close()
+				return
+			default:
+			}
+			if err := c.joined(p, state); err != nil {
+				c.update(Update{Err: err})
+				p.conn.Close()
+				return
+			}
+			go c.receive(p)
+			return
+		}
+
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// Delivers an update unless the client is closed
+func (c *Client) update(update Update) {
+	select {
+	case c.updates <- update:
+	case <-c.closed:
+	}
+}
+
+// The side the client plays
+func (c *Client) Color() chess.Color {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.color
+}
+
+// What the server sends.  Apply brings the client's copy of the game up to date with each update.
+func (c *Client) Updates() <-chan Update {
+	return c.updates
+}
+
+// Brings game, the client's copy, up to date with an update.  Returns the game to carry on with, which is a
+// new one when the server sent the whole game.  A move that doesn't fit game makes the client ask for the
+// whole game.
+func (c *Client) Apply(game *chess.Game, update Update) *chess.Game {
+	switch {
+	case update.Game != nil:
+		return update.Game
+	case update.Move != (chess.Move{}):
+		if update.Ply != len(game.Moves()) || game.Play(update.Move) != nil {
+			c.Sync()
+		}
+	}
+	return game
+}
+
+// Asks the server to play move on game, the client's copy.  The move is played when the server sends it back.
+func (c *Client) Play(game *chess.Game, move chess.Move) error {
+	return c.send(message{Type: moveMessage, Move: move.String(), Ply: len(game.Moves())})
+}
+
+// Resigns the game
+func (c *Client) Resign() error {
+	return c.send(message{Type: resignMessage})
+}
+
+// Claims a draw if the client's side may, otherwise offers one or accepts the opponent's offer
+func (c *Client) Draw() error {
+	return c.send(message{Type: drawMessage})
+}
+
+// Asks the server for the whole game
+func (c *Client) Sync() error {
+	return c.send(message{Type: syncMessage})
+}
+
+func (c *Client) send(msg message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.peer == nil {
+		return errors.New("not connected to the server")
+	}
+	return c.peer.send(msg)
+}
+
+// Leaves the game.  The seat is kept on the server.  Closing the client again does nothing.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.peer != nil {
+			err = c.peer.conn.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,231 @@
+package netplay
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+// A client together with its copy of the game
+type testPlayer struct {
+	t      *testing.T
+	client *Client
+	game   *chess.Game
+}
+
+func startServer(t *testing.T, game *chess.Game) string {
+	t.Helper()
+	server, err := Listen("127.0.0.1:0", game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+	return server.Addr().String()
+}
+
+func joinGame(t *testing.T, addr string, color chess.Color) *testPlayer {
+	t.Helper()
+	client, err := Dial(addr, color)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	player := &testPlayer{t: t, client: client}
+	player.next()
+	return player
+}
+
+// Waits for the next update and applies it
+func (p *testPlayer) next() Update {
+	p.t.Helper()
+	select {
+	case update := <-p.client.Updates():
+		p.game = p.client.Apply(p.game, update)
+		return update
+	case <-time.After(5 * time.Second):
+		p.t.Fatal("no update from the server")
+		return Update{}
+	}
+}
+
+func (p *testPlayer) play(notation string) {
+	p.t.Helper()
+	move, err := p.game.ParseMove(notation)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if err := p.client.Play(p.game, move); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// The position after playing moves from the start
+func positionAfter(t *testing.T, moves ...string) string {
+	t.Helper()
+	game := chess.NewGame()
+	for _, notation := range moves {
+		move, err := game.ParseMove(notation)
+		if err == nil {
+			err = game.Play(move)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game.FEN()
+}
+
+func TestPlayersPlayThroughServer(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	white := joinGame(t, addr, chess.White)
+	black := joinGame(t, addr, 0)
+	if black.client.Color() != chess.Black {
+		t.Fatalf("second player plays %s, want black", black.client.Color())
+	}
+
+	for i, notation := range []string{"f3", "e5", "g4", "Qh4#"} {
+		player, opponent := white, black
+		if i%2 == 1 {
+			player, opponent = black, white
+		}
+		player.play(notation)
+		player.next()
+		opponent.next()
+	}
+
+	want := positionAfter(t, "f3", "e5", "g4", "Qh4#")
+	for _, player := range []*testPlayer{white, black} {
+		if player.game.Result() != chess.BlackWon || player.game.FEN() != want {
+			t.Errorf("%s has %q with result %v, want %q with result %v", player.client.Color(), player.game.FEN(), player.game.Result(), want, chess.BlackWon)
+		}
+	}
+}
+
+// Closing a client again, as deferred and cleanup calls may, does nothing
+func TestClientClosesOnce(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	player := joinGame(t, addr, chess.White)
+	if err := player.client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := player.client.Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+}
+
+func TestServerRefusesMoves(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	white := joinGame(t, addr, chess.White)
+	black := joinGame(t, addr, chess.Black)
+
+	// Out of turn
+	black.client.send(message{Type: moveMessage, Move: "e7e5"})
+	if update := black.next(); update.Err == nil {
+		t.Fatal("black moved on white's turn")
+	}
+	if update := black.next(); update.Game == nil || len(update.Game.Moves()) != 0 {
+		t.Fatal("the refusal did not come with the game")
+	}
+
+	// Illegal, sent without checking it first
+	white.client.send(message{Type: moveMessage, Move: "e2e5"})
+	if update := white.next(); update.Err == nil {
+		t.Fatal("the server accepted an illegal move")
+	}
+	white.next()
+
+	// A second seat for the same color
+	if _, err := Dial(addr, chess.White); err == nil {
+		t.Fatal("joined as white twice")
+	}
+}
+
+func TestClientRejoinsAfterLosingConnection(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	white := joinGame(t, addr, chess.White)
+	black := joinGame(t, addr, chess.Black)
+
+	white.play("e4")
+	white.next()
+	black.next()
+
+	white.client.mu.Lock()
+	white.client.peer.conn.Close()
+	white.client.mu.Unlock()
+	if update := white.next(); update.Err == nil {
+		t.Fatal("the lost connection was not reported")
+	}
+
+	// Black moves while white is away, or just back
+	black.play("c5")
+	black.next()
+
+	// White gets its seat back and the whole game, then any move it missed
+	if update := white.next(); update.Game == nil {
+		t.Fatal("white did not get the game on rejoining")
+	}
+	for len(white.game.Moves()) < 2 {
+		white.next()
+	}
+	if want := positionAfter(t, "e4", "c5"); white.client.Color() != chess.White || white.game.FEN() != want {
+		t.Fatalf("white rejoined as %s at %q, want %q", white.client.Color(), white.game.FEN(), want)
+	}
+
+	white.play("Nf3")
+	white.next()
+	black.next()
+	if want := positionAfter(t, "e4", "c5", "Nf3"); black.game.FEN() != want {
+		t.Errorf("black is at %q, want %q", black.game.FEN(), want)
+	}
+}
+
+func TestResignationAndDrawOffers(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	white := joinGame(t, addr, chess.White)
+	black := joinGame(t, addr, chess.Black)
+
+	white.client.Draw()
+	if update := black.next(); !update.DrawOffer {
+		t.Fatal("black was not offered a draw")
+	}
+	black.client.Draw()
+	white.next()
+	black.next()
+	for _, player := range []*testPlayer{white, black} {
+		if outcome := player.game.Outcome(); outcome.Result() != chess.Drawn || outcome.Termination() != chess.Agreement {
+			t.Errorf("%s has outcome %v", player.client.Color(), outcome)
+		}
+	}
+
+	addr = startServer(t, chess.NewGame())
+	white = joinGame(t, addr, chess.White)
+	black = joinGame(t, addr, chess.Black)
+	black.client.Resign()
+	white.next()
+	if outcome := white.game.Outcome(); outcome.Result() != chess.WhiteWon || outcome.Termination() != chess.Resignation {
+		t.Errorf("white has outcome %v after black resigned", outcome)
+	}
+}
+
+func TestServerTurnsAwayOtherProtocolVersions(t *testing.T) {
+	addr := startServer(t, chess.NewGame())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	json.NewEncoder(conn).Encode(message{Version: ProtocolVersion + 1, Type: joinMessage})
+	var reply message
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Type != errorMessage || !strings.Contains(reply.Error, "version") {
+		t.Errorf("server answered %+v", reply)
+	}
+}
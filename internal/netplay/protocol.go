@@ -0,0 +1,147 @@
+// Package netplay lets two players on different machines play a game through a server that keeps the
+// authoritative copy of it.
+//
+// Peers exchange JSON messages, one per line, each carrying the protocol version.  A client opens with a join
+// message asking for a color, or giving back the token it was handed to take its seat again after losing its
+// connection.  The server answers with the whole game as a state message: the starting FEN, the moves played
+// since in long algebraic notation and how the game ended.  From then on the server checks each move a client
+// sends against its own game and sends it on to both players, so that neither board changes without the server
+// having accepted the move.
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+// Version of the message format.  Peers speaking another version are turned away.
+const ProtocolVersion = 1
+
+// How long a peer may take to accept a message before its connection is dropped
+const writeTimeout = 5 * time.Second
+
+// Message types
+const (
+	joinMessage      = "join"      // Client asks for a seat: Color, or Token to take its seat back
+	stateMessage     = "state"     // Server sends the whole game: FEN, Moves, Result and Termination, and the client's Color and Token
+	moveMessage      = "move"      // Client plays, or server relays, Move as the move numbered Ply from the start, counting from 0
+	resignMessage    = "resign"    // Client resigns
+	drawMessage      = "draw"      // Client claims a draw, offers one, or accepts the opponent's offer
+	drawOfferMessage = "drawoffer" // Server tells a client that its opponent offers a draw
+	syncMessage      = "sync"      // Client has fallen out of step and asks for the whole game
+	errorMessage     = "error"     // Server refuses a request, explained in Error
+)
+
+type message struct {
+	Version     int      `json:"version"`
+	Type        string   `json:"type"`
+	Color       string   `json:"color,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	Move        string   `json:"move,omitempty"`
+	Ply         int      `json:"ply,omitempty"`
+	FEN         string   `json:"fen,omitempty"`
+	Moves       []string `json:"moves,omitempty"`
+	Result      string   `json:"result,omitempty"`
+	Termination string   `json:"termination,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// A connection exchanging messages
+type peer struct {
+	conn    net.Conn
+	decoder *json.Decoder
+}
+
+func newPeer(conn net.Conn) *peer {
+	return &peer{conn: conn, decoder: json.NewDecoder(bufio.NewReader(conn))}
+}
+
+// Sends a message, stamped with the protocol version
+func (p *peer) send(msg message) error {
+	msg.Version = ProtocolVersion
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err = p.conn.Write(append(line, '\n'))
+	return err
+}
+
+// Waits for the next message.  Messages of another protocol version are an error.
+func (p *peer) receive() (message, error) {
+	var msg message
+	if err := p.decoder.Decode(&msg); err != nil {
+		return message{}, err
+	}
+	if msg.Version != ProtocolVersion {
+		return message{}, fmt.Errorf("peer speaks protocol version %d, want %d", msg.Version, ProtocolVersion)
+	}
+	return msg, nil
+}
+
+// Describes a game as a state message
+func stateOf(game *chess.Game) message {
+	moves := game.Moves()
+	notations := make([]string, len(moves))
+	for i, move := range moves {
+		notations[i] = move.String()
+	}
+	outcome := game.Outcome()
+	return message{
+		Type:        stateMessage,
+		FEN:         game.StartFEN(),
+		Moves:       notations,
+		Result:      outcome.Result().String(),
+		Termination: outcome.Termination().String(),
+	}
+}
+
+// Rebuilds the game described by a state message by replaying its moves from its starting position
+func gameOf(msg message) (*chess.Game, error) {
+	game, err := chess.NewGameFromFEN(msg.FEN)
+	if err != nil {
+		return nil, err
+	}
+	for i, notation := range msg.Moves {
+		move, err := game.ParseMove(notation)
+		if err == nil {
+			err = game.Play(move)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	// Endings that the moves alone don't decide
+	switch msg.Termination {
+	case chess.Resignation.String():
+		loser := chess.White
+		if msg.Result == chess.WhiteWon.String() {
+			loser = chess.Black
+		}
+		game.Resign(loser)
+	case chess.Agreement.String():
+		game.AgreeDraw()
+	case chess.Repetition.String(), chess.FiftyMoveRule.String():
+		game.ClaimDraw()
+	}
+	return game, nil
+}
+
+func parseColor(name string) (chess.Color, error) {
+	switch name {
+	case "":
+		return 0, nil
+	case chess.White.String():
+		return chess.White, nil
+	case chess.Black.String():
+		return chess.Black, nil
+	}
+	return 0, fmt.Errorf("%q is not a color", name)
+}
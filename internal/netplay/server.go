@@ -0,0 +1,251 @@
+package netplay
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+// Hosts a game between two clients.  Moves are only played once the server's copy of the game accepts them.
+type Server struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	game      *chess.Game
+	seats     [3]seat     // By color
+	drawOffer chess.Color // Side offering a draw, zero for none
+	closed    bool
+}
+
+// A player's place in the game, kept while the player is disconnected
+type seat struct {
+	token string // Proves a returning client is the player who joined, "" until someone does
+	peer  *peer  // nil while the player is disconnected
+}
+
+// Starts listening on addr, such as ":7878", for the players of game.  Serve accepts them.
+func Listen(addr string, game *chess.Game) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{listener: listener, game: game}, nil
+}
+
+// The address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Accepts players until the server is closed
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(newPeer(conn))
+	}
+}
+
+// Stops accepting players and disconnects the ones playing
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, seat := range s.seats {
+		if seat.peer != nil {
+			seat.peer.conn.Close()
+		}
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) handleConn(p *peer) {
+	defer p.conn.Close()
+
+	join, err := p.receive()
+	if err == nil && join.Type != joinMessage {
+		err = fmt.Errorf("expected a join message, got %q", join.Type)
+	}
+	if err != nil {
+		p.send(message{Type: errorMessage, Error: err.Error()})
+		return
+	}
+	color, err := s.seat(p, join)
+	if err != nil {
+		p.send(message{Type: errorMessage, Error: err.Error()})
+		return
+	}
+
+	for {
+		msg, err := p.receive()
+		if err != nil {
+			break
+		}
+		s.handle(p, color, msg)
+	}
+
+	s.mu.Lock()
+	if s.seats[color].peer == p {
+		s.seats[color].peer = nil
+	}
+	s.mu.Unlock()
+}
+
+// Seats a joining player, or gives a returning one back its seat, and sends it the game
+func (s *Server) seat(p *peer, join message) (chess.Color, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var color chess.Color
+	if join.Token != "" {
+		for _, side := range []chess.Color{chess.White, chess.Black} {
+			if s.seats[side].token == join.Token {
+				color = side
+			}
+		}
+		if color == 0 {
+			return 0, errors.New("unknown token, the seat may belong to another game")
+		}
+		// The old connection may not have noticed it is gone yet
+		if old := s.seats[color].peer; old != nil {
+			old.conn.Close()
+		}
+	} else {
+		wanted, err := parseColor(join.Color)
+		if err != nil {
+			return 0, err
+		}
+		for _, side := range []chess.Color{chess.White, chess.Black} {
+			if s.seats[side].token == "" && (wanted == 0 || wanted == side) {
+				color = side
+				break
+			}
+		}
+		if color == 0 {
+			if wanted != 0 {
+				return 0, fmt.Errorf("%s is already taken", wanted)
+			}
+			return 0, errors.New("the game is full")
+		}
+		token, err := newToken()
+		if err != nil {
+			return 0, err
+		}
+		s.seats[color].token = token
+	}
+
+	s.seats[color].peer = p
+	state := stateOf(s.game)
+	state.Color = color.String()
+	state.Token = s.seats[color].token
+	if err := p.send(state); err != nil {
+		return 0, err
+	}
+	log.Printf("%s joined from %s", color, p.conn.RemoteAddr())
+	return color, nil
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Carries out a request from the player of color
+func (s *Server) handle(p *peer, color chess.Color, msg message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch msg.Type {
+	case moveMessage:
+		if err := s.play(color, msg); err != nil {
+			// The player's board may be out of step, so it gets the game along with the refusal
+			p.send(message{Type: errorMessage, Error: err.Error()})
+			s.sendState(color)
+			return
+		}
+		s.drawOffer = 0
+		s.broadcast(message{Type: moveMessage, Move: msg.Move, Ply: msg.Ply})
+	case resignMessage:
+		s.game.Resign(color)
+		s.broadcastState()
+	case drawMessage:
+		s.draw(color)
+	case syncMessage:
+		s.sendState(color)
+	default:
+		p.send(message{Type: errorMessage, Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+func (s *Server) play(color chess.Color, msg message) error {
+	if s.game.Turn() != color {
+		return fmt.Errorf("it is %s's turn", s.game.Turn())
+	}
+	if msg.Ply != len(s.game.Moves()) {
+		return fmt.Errorf("move %s was played on move %d of the game, which is on move %d", msg.Move, msg.Ply, len(s.game.Moves()))
+	}
+	move, err := s.game.ParseMove(msg.Move)
+	if err != nil {
+		return err
+	}
+	return s.game.Play(move)
+}
+
+// Claims a draw for color if it may, otherwise offers one or accepts the opponent's offer
+func (s *Server) draw(color chess.Color) {
+	if s.game.Outcome().IsOver() {
+		return
+	}
+	if s.game.Turn() == color && s.game.ClaimDraw() {
+		s.broadcastState()
+		return
+	}
+	if s.drawOffer == color.Opponent() {
+		s.game.AgreeDraw()
+		s.broadcastState()
+		return
+	}
+	s.drawOffer = color
+	if opponent := s.seats[color.Opponent()].peer; opponent != nil {
+		opponent.send(message{Type: drawOfferMessage, Color: color.String()})
+	}
+}
+
+func (s *Server) sendState(color chess.Color) {
+	if p := s.seats[color].peer; p != nil {
+		state := stateOf(s.game)
+		state.Color = color.String()
+		p.send(state)
+	}
+}
+
+func (s *Server) broadcastState() {
+	s.sendState(chess.White)
+	s.sendState(chess.Black)
+}
+
+// Sends a message to both players.  A player who can't be reached catches up on reconnecting.
+func (s *Server) broadcast(msg message) {
+	for _, seat := range s.seats {
+		if seat.peer != nil {
+			seat.peer.send(msg)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+// Solid glyphs for both sides, coloured by the terminal, read better on coloured squares than the outlined
+// white glyphs
+var pieceGlyphs = map[chess.PieceType]string{
+	chess.Pawn:   "♟",
+	chess.Knight: "♞",
+	chess.Bishop: "♝",
+	chess.Rook:   "♜",
+	chess.Queen:  "♛",
+	chess.King:   "♚",
+}
+
+var pieceLetters = map[chess.PieceType]string{
+	chess.Pawn:   "p",
+	chess.Knight: "n",
+	chess.Bishop: "b",
+	chess.Rook:   "r",
+	chess.Queen:  "q",
+	chess.King:   "k",
+}
+
+// 24-bit ANSI colours matching the window's board
+const (
+	lightSquareColor    = "238;238;238" // Off white
+	darkSquareColor     = "118;150;86"  // Green
+	lightHighlightColor = "243;243;166" // Squares tinted yellow, as in the window
+	darkHighlightColor  = "159;181;60"
+	whitePieceColor     = "255;255;255"
+	blackPieceColor     = "0;0;0"
+	ansiReset           = "\x1b[0m"
+)
+
+// Marks a highlighted empty square on a coloured board
+const emptyHighlightMarker = "·"
+
+// Draws the board as text, white at the bottom unless flipped.  The squares in highlights are marked, as the
+// window marks a selected piece's moves.  Without colour the board is drawn in ASCII, white in upper case.
+func renderBoard(game *chess.Game, highlights map[chess.Square]bool, colored bool, flipped bool) string {
+	var board strings.Builder
+
+	files := "    a  b  c  d  e  f  g  h\n"
+	if flipped {
+		files = "    h  g  f  e  d  c  b  a\n"
+	}
+	board.WriteString(files)
+	for row := 0; row < 8; row++ {
+		rank := 7 - row
+		if flipped {
+			rank = row
+		}
+		fmt.Fprintf(&board, " %d ", rank+1)
+		for column := 0; column < 8; column++ {
+			file := column
+			if flipped {
+				file = 7 - column
+			}
+			square := chess.SquareAt(file, rank)
+			if colored {
+				board.WriteString(coloredSquare(game.Piece(square), (file+rank)%2 == 1, highlights[square]))
+			} else {
+				board.WriteString(plainSquare(game.Piece(square), highlights[square]))
+			}
+		}
+		fmt.Fprintf(&board, " %d\n", rank+1)
+	}
+	board.WriteString(files)
+
+	return board.String()
+}
+
+func coloredSquare(piece chess.Piece, light bool, highlighted bool) string {
+	background := darkSquareColor
+	switch {
+	case light && highlighted:
+		background = lightHighlightColor
+	case light:
+		background = lightSquareColor
+	case highlighted:
+		background = darkHighlightColor
+	}
+
+	symbol := " "
+	foreground := blackPieceColor
+	if piece.Type != 0 {
+		symbol = pieceGlyphs[piece.Type]
+		if piece.Color == chess.White {
+			foreground = whitePieceColor
+		}
+	} else if highlighted {
+		symbol = emptyHighlightMarker
+	}
+	return fmt.Sprintf("\x1b[48;2;%sm\x1b[38;2;%sm %s %s", background, foreground, symbol, ansiReset)
+}
+
+func plainSquare(piece chess.Piece, highlighted bool) string {
+	symbol := "."
+	if piece.Type != 0 {
+		symbol = pieceLetters[piece.Type]
+		if piece.Color == chess.White {
+			symbol = strings.ToUpper(symbol)
+		}
+	}
+	if !highlighted {
+		return " " + symbol + " "
+	}
+	if piece.Type == 0 {
+		return " * "
+	}
+	return "(" + symbol + ")"
+}
@@ -0,0 +1,316 @@
+// Package tui plays It's Chess in a terminal, for machines where the window can't open such as over SSH.  It
+// plays by the same chess package as the window.
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+// Thinking time per move when neither a depth nor a time is given
+const defaultEngineMoveTime = time.Second
+
+const helpText = `Type a move in SAN (Nf3, exd5, O-O, e8=Q) or coordinates (g1f3, e7e8q), or a command:
+  moves [square]  highlight the moves of the piece on square, or list every legal move
+  board           draw the board again
+  flip            turn the board around
+  undo, redo      take back a move, or replay one taken back
+  draw            claim a draw by repetition or the fifty-move rule
+  agree           agree a draw
+  resign          resign for the side to move
+  fen, pgn        print the position or the game
+  quit            leave
+`
+
+// Settings for a terminal game
+type Options struct {
+	Color          bool          // Draw with ANSI colours and Unicode pieces rather than plain ASCII
+	Flip           bool          // Draw black at the bottom of the board
+	EngineColor    string        // Side played by the engine: "white", "black", "both", or "" to play without it
+	EngineDepth    int           // Maximum depth the engine searches to, 0 for no limit
+	EngineMoveTime time.Duration // Time the engine thinks per move, 0 for no limit.  One second if neither limit is set.
+	EngineWeights  string        // JSON file of evaluation weights for the engine, "" for the built in ones
+	EnginePath     string        // UCI engine program to use instead of the built-in engine, such as Stockfish
+}
+
+type session struct {
+	game    *chess.Game
+	lines   *bufio.Scanner
+	out     io.Writer
+	options Options
+
+	engine       *chess.Engine
+	engineColors [3]bool // By color, whether the engine plays that side
+	engineLimits chess.SearchLimits
+}
+
+// Plays game with moves and commands read from in, drawing the board to out, until in ends or the player quits
+func Run(game *chess.Game, in io.Reader, out io.Writer, options Options) error {
+	s := &session{game: game, lines: bufio.NewScanner(in), out: out, options: options}
+
+	if options.EngineColor != "" {
+		if err := s.setEngine(); err != nil {
+			return err
+		}
+		defer s.engine.Close()
+	}
+
+	s.drawBoard(nil)
+	s.reportOutcome()
+	for {
+		if s.engineToMove() {
+			s.playEngineMove()
+			continue
+		}
+
+		fmt.Fprintf(s.out, "%s> ", s.game.Turn())
+		line, ok := s.readLine()
+		if !ok {
+			return s.lines.Err()
+		}
+		if quit := s.handle(line); quit {
+			return nil
+		}
+	}
+}
+
+func (s *session) setEngine() error {
+	switch s.options.EngineColor {
+	case "white":
+		s.engineColors[chess.White] = true
+	case "black":
+		s.engineColors[chess.Black] = true
+	case "both":
+		s.engineColors[chess.White], s.engineColors[chess.Black] = true, true
+	default:
+		return fmt.Errorf("engine color must be white, black or both, got %q", s.options.EngineColor)
+	}
+
+	s.engineLimits = chess.SearchLimits{Depth: s.options.EngineDepth, MoveTime: s.options.EngineMoveTime}
+	if s.engineLimits.Depth == 0 && s.engineLimits.MoveTime == 0 {
+		s.engineLimits.MoveTime = defaultEngineMoveTime
+	}
+
+	var err error
+	switch {
+	case s.options.EnginePath != "":
+		s.engine, err = chess.StartUCIEngine(s.options.EnginePath)
+	case s.options.EngineWeights != "":
+		s.engine, err = chess.NewEngineWithWeights(s.options.EngineWeights)
+	default:
+		s.engine = chess.NewEngine()
+	}
+	return err
+}
+
+// Reads the next non-blank line, or returns false at the end of the input
+func (s *session) readLine() (string, bool) {
+	for s.lines.Scan() {
+		if line := strings.TrimSpace(s.lines.Text()); line != "" {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// Carries out one line of input.  Reports whether the player quit.
+func (s *session) handle(line string) bool {
+	fields := strings.Fields(line)
+	switch strings.ToLower(fields[0]) {
+	case "help", "?":
+		fmt.Fprint(s.out, helpText)
+	case "moves":
+		s.showMoves(fields[1:])
+	case "board":
+		s.drawBoard(nil)
+	case "flip":
+		s.options.Flip = !s.options.Flip
+		s.drawBoard(nil)
+	case "undo":
+		s.undoMove()
+	case "redo":
+		s.redoMove()
+	case "draw":
+		if !s.game.ClaimDraw() {
+			fmt.Fprintln(s.out, "There is no draw to claim")
+			return false
+		}
+		s.reportOutcome()
+	case "agree":
+		s.game.AgreeDraw()
+		s.reportOutcome()
+	case "resign":
+		s.game.Resign(s.game.Turn())
+		s.reportOutcome()
+	case "fen":
+		fmt.Fprintln(s.out, s.game.FEN())
+	case "pgn":
+		pgn, err := s.game.PGN()
+		if err != nil {
+			fmt.Fprintln(s.out, err)
+			return false
+		}
+		fmt.Fprint(s.out, pgn)
+	case "quit", "exit":
+		return true
+	default:
+		s.playInput(line)
+	}
+	return false
+}
+
+// Highlights the moves of the piece on the named square, or lists all legal moves with no square named
+func (s *session) showMoves(args []string) {
+	if len(args) == 0 {
+		var sanMoves []string
+		for _, move := range s.game.LegalMoves() {
+			san, _ := s.game.SAN(move)
+			sanMoves = append(sanMoves, san)
+		}
+		if len(sanMoves) == 0 {
+			fmt.Fprintln(s.out, "No legal moves")
+			return
+		}
+		fmt.Fprintln(s.out, strings.Join(sanMoves, " "))
+		return
+	}
+
+	square, err := chess.ParseSquare(args[0])
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	moves := s.game.LegalMovesFrom(square)
+	if len(moves) == 0 {
+		fmt.Fprintf(s.out, "No legal moves from %s\n", square)
+		return
+	}
+	highlights := map[chess.Square]bool{square: true}
+	for _, move := range moves {
+		highlights[move.To] = true
+	}
+	s.drawBoard(highlights)
+}
+
+// Plays a move typed by the player.  A pawn move to the last rank without a piece to promote to asks for one.
+func (s *session) playInput(text string) {
+	move, err := s.game.ParseMove(text)
+	if err != nil {
+		// Both notations take the promotion piece as a trailing letter, so a queen promotion tells whether
+		// the move promotes
+		promotion, promotionErr := s.game.ParseMove(strings.TrimRight(text, "+#") + "q")
+		if promotionErr != nil || promotion.Promotion == 0 {
+			fmt.Fprintln(s.out, err)
+			return
+		}
+		pieceType, ok := s.askPromotion()
+		if !ok {
+			return
+		}
+		move = promotion
+		move.Promotion = pieceType
+	}
+	s.playMove(move)
+}
+
+// Asks which piece to promote to until given one.  Returns false if the input ends first.
+func (s *session) askPromotion() (chess.PieceType, bool) {
+	for {
+		fmt.Fprint(s.out, "Promote to (q, r, b, n)> ")
+		line, ok := s.readLine()
+		if !ok {
+			return 0, false
+		}
+		switch strings.ToLower(line) {
+		case "q", "queen":
+			return chess.Queen, true
+		case "r", "rook":
+			return chess.Rook, true
+		case "b", "bishop":
+			return chess.Bishop, true
+		case "n", "knight":
+			return chess.Knight, true
+		}
+	}
+}
+
+func (s *session) playMove(move chess.Move) {
+	san, err := s.game.SAN(move)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	if err := s.game.Play(move); err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+
+	s.drawBoard(nil)
+	if s.game.Turn() == chess.Black {
+		fmt.Fprintf(s.out, "%d. %s\n", s.game.FullmoveNumber(), san)
+	} else {
+		fmt.Fprintf(s.out, "%d... %s\n", s.game.FullmoveNumber()-1, san)
+	}
+	s.reportOutcome()
+}
+
+// Prints how the game ended, or a draw the side to move may claim
+func (s *session) reportOutcome() {
+	if outcome := s.game.Outcome(); outcome.IsOver() {
+		fmt.Fprintln(s.out, outcome)
+	} else if termination := s.game.ClaimableDraw(); termination != chess.NotTerminated {
+		fmt.Fprintf(s.out, "%s may claim a draw by %s (type draw)\n", s.game.Turn(), termination)
+	} else if s.game.InCheck() {
+		fmt.Fprintf(s.out, "%s is in check\n", s.game.Turn())
+	}
+}
+
+// Takes back the last move, and the engine's reply before it so that the player is to move again
+func (s *session) undoMove() {
+	if _, ok := s.game.Undo(); !ok {
+		fmt.Fprintln(s.out, "There is no move to take back")
+		return
+	}
+	if s.engineToMove() && !s.engineColors[s.game.Turn().Opponent()] {
+		s.game.Undo()
+	}
+	fmt.Fprintln(s.out, "Took back")
+	s.drawBoard(nil)
+}
+
+func (s *session) redoMove() {
+	if _, ok := s.game.Redo(); !ok {
+		fmt.Fprintln(s.out, "There is no move to replay")
+		return
+	}
+	s.drawBoard(nil)
+}
+
+func (s *session) engineToMove() bool {
+	return s.engineColors[s.game.Turn()] && !s.game.Outcome().IsOver()
+}
+
+// Waits for the engine's move and plays it.  An engine that doesn't give one hands its side to the player.
+func (s *session) playEngineMove() {
+	fmt.Fprintf(s.out, "%s is thinking...\n", s.engine.Name())
+	found := make(chan chess.Move, 1)
+	s.engine.Start(s.game, s.engineLimits, nil, func(move chess.Move) {
+		found <- move
+	})
+	move := <-found
+	if move == (chess.Move{}) {
+		fmt.Fprintln(s.out, "The engine did not return a move, handing its side over to the player")
+		s.engineColors = [3]bool{}
+		return
+	}
+	s.playMove(move)
+}
+
+func (s *session) drawBoard(highlights map[chess.Square]bool) {
+	fmt.Fprint(s.out, renderBoard(s.game, highlights, s.options.Color, s.options.Flip))
+}
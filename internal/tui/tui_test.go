@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benwheeler12/itschess/chess"
+)
+
+func TestRunPlaysMovesInBothNotations(t *testing.T) {
+	game := chess.NewGame()
+	var out strings.Builder
+	input := "e4\ne7e5\nBc4\nb8c6\nQh5\nNf6\nQxf7#\n"
+	if err := Run(game, strings.NewReader(input), &out, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Result() != chess.WhiteWon {
+		t.Errorf("result = %v, want %v", game.Result(), chess.WhiteWon)
+	}
+	if !strings.Contains(out.String(), "4. Qxf7#") {
+		t.Errorf("output does not record the mating move:\n%s", out.String())
+	}
+}
+
+func TestRunAsksForPromotionPiece(t *testing.T) {
+	for _, input := range []string{"e8\nx\nn\n", "e7e8\nknight\n"} {
+		game, err := chess.NewGameFromFEN("8/4P1k1/8/8/8/8/8/4K3 w - - 0 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		if err := Run(game, strings.NewReader(input), &out, Options{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if piece := game.Piece(chess.SquareAt(4, 7)); piece != (chess.Piece{Type: chess.Knight, Color: chess.White}) {
+			t.Errorf("input %q: e8 holds %v, want a white knight", input, piece)
+		}
+	}
+}
+
+func TestRenderBoardHighlightsMoves(t *testing.T) {
+	game := chess.NewGame()
+	e2, _ := chess.ParseSquare("e2")
+	highlights := map[chess.Square]bool{e2: true}
+	for _, move := range game.LegalMovesFrom(e2) {
+		highlights[move.To] = true
+	}
+
+	board := renderBoard(game, highlights, false, false)
+	for _, row := range []string{
+		" 4  .  .  .  .  *  .  .  .  4",
+		" 3  .  .  .  .  *  .  .  .  3",
+		" 2  P  P  P  P (P) P  P  P  2",
+	} {
+		if !strings.Contains(board, row) {
+			t.Errorf("board is missing row %q:\n%s", row, board)
+		}
+	}
+
+	flipped := renderBoard(game, nil, false, true)
+	if !strings.HasPrefix(flipped, "    h  g  f  e  d  c  b  a\n 1  R  N  B  K  Q  B  N  R  1\n") {
+		t.Errorf("flipped board does not start from white's back rank:\n%s", flipped)
+	}
+}